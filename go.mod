@@ -1,12 +1,16 @@
 module example.com
 
-go 1.20
+go 1.22
+
+require (
+	github.com/klauspost/compress v1.18.0
+	github.com/stretchr/testify v1.8.4
+	golang.org/x/exp v0.0.0-20230801115018-d63ba01acd4b
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/stretchr/objx v0.5.0 // indirect
-	github.com/stretchr/testify v1.8.4 // indirect
-	golang.org/x/exp v0.0.0-20230801115018-d63ba01acd4b
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
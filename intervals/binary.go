@@ -0,0 +1,367 @@
+package intervals
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// The Binary encoding starts with a header of
+//
+//	magic  "IVB\x01"
+//	width  1 byte: how endpoints are stored
+//	count  8 bytes, little endian: the number of intervals,
+//	       or unknownCount if it was not known when writing
+//
+// followed by the intervals, each a byte holding its Bounds
+// and its two endpoints. Endpoints are stored according to
+// width:
+//
+//	varintWidth  as varints of the difference to the bits of
+//	             the previous endpoint, which are small for
+//	             sorted integers
+//	8            as their 64 bits, e.g. for Float64
+//	textWidth    as the text of Domain.Format, preceded by
+//	             its length as a uvarint
+const (
+	binaryMagic = "IVB\x01"
+
+	varintWidth = 0
+	textWidth   = 0xff
+
+	// countOffset is the offset of the count of intervals
+	// in the header.
+	countOffset = len(binaryMagic) + 1
+	headerSize  = countOffset + 8
+
+	unknownCount = math.MaxUint64
+
+	// maxTextEndpoint bounds the memory allocated for an
+	// endpoint stored as text, in case of corrupt input.
+	maxTextEndpoint = 1 << 24
+)
+
+// errTruncated is returned for input ending within an
+// interval.
+var errTruncated = fmt.Errorf("binary input ends within interval: %w", errBadInput)
+
+// binaryCodec converts the endpoints of a domain from and
+// to the 64 bits stored in the Binary encoding. Domains
+// without one are stored as text.
+type binaryCodec[T any] struct {
+	// width is varintWidth for integers, 8 otherwise.
+	width uint8
+
+	bits     func(v T) uint64
+	fromBits func(b uint64) (T, error)
+}
+
+// binaryWidth returns the width of the endpoints of domain
+// d in the Binary encoding.
+func binaryWidth[T any](d Domain[T]) uint8 {
+	if d.binary == nil {
+		return textWidth
+	}
+	return d.binary.width
+}
+
+// binaryEncoder writes intervals in the Binary encoding.
+type binaryEncoder[T any] struct {
+	w     *bufio.Writer
+	d     Domain[T]
+	width uint8
+
+	// count is the number of intervals written to the
+	// header, n the number of intervals encoded so far
+	count, n uint64
+
+	// bits of the previous endpoint, for varintWidth
+	prev uint64
+	buf  []byte
+}
+
+// newBinaryEncoder returns a binaryEncoder writing count
+// intervals, or unknownCount, with endpoints in domain d
+// to w.
+func newBinaryEncoder[T any](w io.Writer, d Domain[T], count uint64) *binaryEncoder[T] {
+	return &binaryEncoder[T]{w: bufio.NewWriter(w), d: d, width: binaryWidth(d), count: count}
+}
+
+func (enc *binaryEncoder[T]) encode(i Interval[T]) error {
+	if enc.n == 0 {
+		enc.writeHeader()
+	}
+	enc.n++
+
+	enc.buf = append(enc.buf[:0], byte(i.Bounds))
+	enc.buf = enc.appendEndpoint(enc.buf, i.X)
+	enc.buf = enc.appendEndpoint(enc.buf, i.Y)
+	_, err := enc.w.Write(enc.buf)
+	return err
+}
+
+func (enc *binaryEncoder[T]) flush() error {
+	if enc.n == 0 {
+		enc.writeHeader()
+	}
+	if enc.count != unknownCount && enc.n != enc.count {
+		return fmt.Errorf("wrote %d intervals, header announced %d", enc.n, enc.count)
+	}
+
+	return enc.w.Flush()
+}
+
+// writeHeader writes the header to the buffer.
+func (enc *binaryEncoder[T]) writeHeader() {
+	enc.buf = append(enc.buf[:0], binaryMagic...)
+	enc.buf = append(enc.buf, enc.width)
+	enc.buf = binary.LittleEndian.AppendUint64(enc.buf, enc.count)
+	// errors are kept by the bufio.Writer until the next write
+	_, _ = enc.w.Write(enc.buf)
+}
+
+// appendEndpoint appends endpoint v to b.
+func (enc *binaryEncoder[T]) appendEndpoint(b []byte, v T) []byte {
+	switch enc.width {
+	case varintWidth:
+		bits := enc.d.binary.bits(v)
+		b = binary.AppendVarint(b, int64(bits-enc.prev))
+		enc.prev = bits
+		return b
+	case textWidth:
+		s := enc.d.Format(v)
+		b = binary.AppendUvarint(b, uint64(len(s)))
+		return append(b, s...)
+	default:
+		return binary.LittleEndian.AppendUint64(b, enc.d.binary.bits(v))
+	}
+}
+
+// writeCount fills in the count of n intervals in the
+// header of the Binary encoded file f, written with
+// unknownCount.
+func writeCount(f *os.File, n uint64) error {
+	b := binary.LittleEndian.AppendUint64(nil, n)
+	_, err := f.WriteAt(b, int64(countOffset))
+	return err
+}
+
+// binaryDecoder parses intervals in the Binary encoding
+// one at a time, without holding more than a single
+// interval in memory.
+type binaryDecoder[T any] struct {
+	r    *bufio.Reader
+	d    Domain[T]
+	opts ParseOptions
+
+	header bool
+	width  uint8
+	count  uint64
+	// n is the number of intervals read, offset the
+	// number of bytes
+	n      uint64
+	offset int64
+
+	// bits of the previous endpoint, for varintWidth
+	prev uint64
+	buf  []byte
+
+	// err is the I/O error of the reader, if any
+	err error
+}
+
+// newBinaryDecoder returns a binaryDecoder reading
+// intervals with endpoints in domain d from r, validated
+// according to opts.
+func newBinaryDecoder[T any](r io.Reader, d Domain[T], opts ParseOptions) *binaryDecoder[T] {
+	return &binaryDecoder[T]{r: bufio.NewReader(r), d: d, opts: opts}
+}
+
+// next returns the next valid interval of the input.
+// Intervals dropped according to the parse options are
+// skipped. io.EOF is returned at the end of the input.
+func (dec *binaryDecoder[T]) next() (Interval[T], error) {
+	if !dec.header {
+		err := dec.readHeader()
+		if err != nil {
+			return Interval[T]{}, err
+		}
+		dec.header = true
+	}
+
+	for {
+		start := dec.offset
+		bounds, err := dec.ReadByte()
+		if err == io.EOF {
+			if dec.count != unknownCount && dec.n != dec.count {
+				return Interval[T]{}, fmt.Errorf("binary input ends after %d of %d intervals: %w", dec.n, dec.count, errBadInput)
+			}
+			return Interval[T]{}, io.EOF
+		}
+		if err != nil {
+			return Interval[T]{}, err
+		}
+		if dec.n == dec.count {
+			return Interval[T]{}, fmt.Errorf("unexpected data after %d intervals at offset %d: %w", dec.n, start, errBadInput)
+		}
+		dec.n++
+
+		var i Interval[T]
+		var xErr, yErr error
+		i.X, xErr = dec.readEndpoint()
+		i.Y, yErr = dec.readEndpoint()
+
+		// raw is the interval before validation, for error
+		// texts, if read reports that it could be read
+		raw := i
+		read := false
+		ok := false
+		switch {
+		case bounds > byte(Open):
+			err = fmt.Errorf("invalid bounds %d: %w", bounds, errBadInput)
+		case xErr != nil:
+			err = xErr
+		case yErr != nil:
+			err = yErr
+		default:
+			raw.Bounds = Bounds(bounds)
+			read = true
+			i, ok, err = validate(raw, dec.d, dec.opts)
+		}
+		if dec.err != nil {
+			return Interval[T]{}, dec.err
+		}
+		if errors.Is(err, errTruncated) {
+			return Interval[T]{}, fmt.Errorf("interval %d at offset %d: %w", dec.n, start, err)
+		}
+		if err != nil {
+			text := ""
+			if read {
+				text = formatInterval(raw, dec.d)
+			}
+			parseErr := &ParseError{Interval: int(dec.n), Offset: start, Text: text, Err: err}
+			if !dec.opts.skip(parseErr) {
+				return Interval[T]{}, parseErr
+			}
+			continue
+		}
+		if ok {
+			return i, nil
+		}
+	}
+}
+
+// readHeader reads and checks the header. Empty input
+// is an empty list.
+func (dec *binaryDecoder[T]) readHeader() error {
+	header := make([]byte, headerSize)
+	n, err := io.ReadFull(dec.r, header)
+	dec.offset += int64(n)
+	if err == io.EOF {
+		dec.count = 0
+		return nil
+	}
+	if err == io.ErrUnexpectedEOF || err == nil && string(header[:len(binaryMagic)]) != binaryMagic {
+		return fmt.Errorf("input is not in the binary encoding: %w", errBadInput)
+	}
+	if err != nil {
+		return err
+	}
+
+	dec.width = header[len(binaryMagic)]
+	if want := binaryWidth(dec.d); dec.width != want {
+		return fmt.Errorf("binary input has endpoints of width %d, expected %d for the domain: %w", dec.width, want, errBadInput)
+	}
+	dec.count = binary.LittleEndian.Uint64(header[countOffset:])
+	return nil
+}
+
+// readEndpoint reads the next endpoint. Errors other than
+// I/O errors wrap errBadInput.
+func (dec *binaryDecoder[T]) readEndpoint() (T, error) {
+	var zero T
+
+	var bits uint64
+	switch dec.width {
+	case varintWidth:
+		delta, err := binary.ReadVarint(dec)
+		if err != nil {
+			return zero, dec.truncated(err)
+		}
+		bits = dec.prev + uint64(delta)
+		dec.prev = bits
+	case textWidth:
+		size, err := binary.ReadUvarint(dec)
+		if err != nil {
+			return zero, dec.truncated(err)
+		}
+		if size > maxTextEndpoint {
+			return zero, fmt.Errorf("endpoint of %d bytes is too long: %w", size, errBadInput)
+		}
+
+		err = dec.read(int(size))
+		if err != nil {
+			return zero, err
+		}
+		return parseEndpoint(dec.d, string(dec.buf))
+	default:
+		err := dec.read(8)
+		if err != nil {
+			return zero, err
+		}
+		bits = binary.LittleEndian.Uint64(dec.buf)
+	}
+
+	v, err := dec.d.binary.fromBits(bits)
+	if err != nil {
+		return zero, fmt.Errorf("endpoint: %w", err)
+	}
+	return v, nil
+}
+
+// ReadByte reads a single byte, implementing io.ByteReader
+// for the varint functions.
+func (dec *binaryDecoder[T]) ReadByte() (byte, error) {
+	b, err := dec.r.ReadByte()
+	switch {
+	case err == nil:
+		dec.offset++
+	case err != io.EOF:
+		dec.err = err
+	}
+	return b, err
+}
+
+// read reads the next n bytes of the input into dec.buf.
+func (dec *binaryDecoder[T]) read(n int) error {
+	if cap(dec.buf) < n {
+		dec.buf = make([]byte, n)
+	}
+	dec.buf = dec.buf[:n]
+
+	k, err := io.ReadFull(dec.r, dec.buf)
+	dec.offset += int64(k)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		dec.err = err
+	}
+	return dec.truncated(err)
+}
+
+// truncated maps errors reading an endpoint to errors
+// wrapping errBadInput, except for I/O errors of the
+// reader.
+func (dec *binaryDecoder[T]) truncated(err error) error {
+	switch {
+	case err == nil || dec.err != nil:
+		return err
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		return errTruncated
+	default:
+		// varints overflowing 64 bits
+		return fmt.Errorf("%s: %w", err, errBadInput)
+	}
+}
@@ -0,0 +1,125 @@
+package intervals
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	testBinaryRoundTrip(t, []Interval[int64]{{X: math.MinInt64, Y: -5}, {X: -3, Y: 4, Bounds: LeftOpen}, {X: 2, Y: math.MaxInt64, Bounds: Open}}, Int64)
+	testBinaryRoundTrip(t, []Interval[uint64]{{X: 0, Y: 1}, {X: 7, Y: math.MaxUint64, Bounds: RightOpen}}, Uint64)
+	testBinaryRoundTrip(t, []Interval[float64]{{X: math.Inf(-1), Y: -1.5}, {X: 0.1, Y: 1e300}}, Float64)
+	testBinaryRoundTrip(t, []Interval[time.Time]{{X: time.Date(2023, 8, 1, 8, 0, 0, 5, time.UTC), Y: time.Date(2023, 8, 1, 12, 0, 0, 0, time.FixedZone("", 7200))}}, Time)
+	testBinaryRoundTrip(t, []Interval[*big.Int]{{X: big.NewInt(-1), Y: new(big.Int).Lsh(big.NewInt(1), 100)}}, BigInt)
+}
+
+// testBinaryRoundTrip checks that list is parsed back
+// unchanged from its Binary encoding.
+func testBinaryRoundTrip[T any](t *testing.T, list []Interval[T], d Domain[T]) {
+	s := FormatWith(list, d, FormatOptions{Encoding: Binary})
+	res, err := ParseWith(strings.NewReader(s), d, ParseOptions{Encoding: Binary})
+	assert.NoError(t, err, fmt.Sprintf("testcase: %s", Format(list, d)))
+	assert.Equal(t, Format(list, d), Format(res, d), fmt.Sprintf("testcase: %s", Format(list, d)))
+}
+
+func TestBinaryEncoding(t *testing.T) {
+	list := []Interval[int]{{X: 1, Y: 3}, {X: 300, Y: 302, Bounds: RightOpen}}
+	s := FormatWith(list, Int, FormatOptions{Encoding: Binary})
+	assert.Equal(t, "IVB\x01\x00\x02\x00\x00\x00\x00\x00\x00\x00"+"\x00\x02\x04"+"\x02\xd2\x04\x04", s)
+
+	empty := FormatWith(nil, Int, FormatOptions{Encoding: Binary})
+	assert.Equal(t, "IVB\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00", empty)
+
+	// streamed output does not know the number of intervals
+	var w bytes.Buffer
+	enc := newEncoder(&w, Int, FormatOptions{Encoding: Binary})
+	for _, i := range list {
+		assert.NoError(t, enc.encode(i))
+	}
+	assert.NoError(t, enc.flush())
+	assert.Equal(t, "\xff\xff\xff\xff\xff\xff\xff\xff", w.String()[countOffset:headerSize])
+
+	testcases := []struct {
+		input    string
+		expected string
+		err      string
+	}{
+		{input: "", expected: ""},
+		{input: empty, expected: ""},
+		{input: s, expected: "[1,3] [300,302)"},
+		{input: w.String(), expected: "[1,3] [300,302)"},
+		{input: "[1,3]", err: "input is not in the binary encoding"},
+		{input: "[1,3] [300,302)", err: "input is not in the binary encoding"},
+		{input: FormatWith([]Interval[int64]{{X: 1, Y: 3}}, Int64, FormatOptions{Encoding: Binary}), expected: "[1,3]"},
+		{input: FormatWith([]Interval[float64]{{X: 1, Y: 3}}, Float64, FormatOptions{Encoding: Binary}), err: "binary input has endpoints of width 8, expected 0"},
+		{input: s[:len(s)-1], err: "interval 2 at offset 16: binary input ends within interval"},
+		{input: s[:16], err: "binary input ends after 1 of 2 intervals"},
+		{input: s + "\x00\x02\x04", err: "unexpected data after 2 intervals at offset 20"},
+		{input: empty[:headerSize] + "\x07\x02\x04", err: "unexpected data after 0 intervals"},
+		{input: w.String()[:headerSize] + "\x07\x02\x04", err: `invalid interval 1 "" at offset 13: invalid bounds 7`},
+		{input: w.String()[:headerSize] + "\x00\x04\x01", err: `invalid interval 1 "[2,1]" at offset 13: left endpoint is greater than right endpoint`},
+	}
+
+	for _, test := range testcases {
+		res, err := ParseWith(strings.NewReader(test.input), Int, ParseOptions{Encoding: Binary})
+		if test.err != "" {
+			assert.ErrorIs(t, err, errBadInput, fmt.Sprintf("testcase: %+v", test))
+			assert.ErrorContains(t, err, test.err, fmt.Sprintf("testcase: %+v", test))
+			continue
+		}
+
+		assert.NoError(t, err, fmt.Sprintf("testcase: %+v", test))
+		assert.Equal(t, test.expected, Format(res, Int), fmt.Sprintf("testcase: %+v", test))
+	}
+
+	var rejects []string
+	opts := ParseOptions{Encoding: Binary, OnError: SkipOnError, Reject: func(err *ParseError) {
+		rejects = append(rejects, err.Error())
+	}}
+	res, err := ParseWith(strings.NewReader(w.String()[:headerSize]+"\x00\x04\x01"+"\x00\x02\x02"), Int, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "[2,3]", Format(res, Int))
+	assert.Equal(t, []string{`invalid interval 1 "[2,1]" at offset 13: left endpoint is greater than right endpoint`}, rejects)
+}
+
+func TestMergeStreamBinary(t *testing.T) {
+	var input bytes.Buffer
+	for k := 0; k < 100; k++ {
+		fmt.Fprintf(&input, "[%d,%d] ", 1000-10*k, 1000-10*k+5)
+	}
+
+	for _, threshold := range []int64{0, -1} {
+		opts := FileOptions{
+			ChunkSize:         64,
+			FanIn:             3,
+			InMemoryThreshold: threshold,
+			Format:            FormatOptions{Encoding: Binary},
+		}
+
+		var w bytes.Buffer
+		err := MergeStream(bytes.NewReader(input.Bytes()), &w, Int, opts)
+		assert.NoError(t, err, fmt.Sprintf("threshold %d", threshold))
+		assert.Equal(t, uint64(100), uint64(w.Bytes()[countOffset]), fmt.Sprintf("threshold %d", threshold))
+
+		// and back from Binary to Text
+		var text bytes.Buffer
+		err = MergeStream(&w, &text, Int, FileOptions{
+			ChunkSize:         64,
+			InMemoryThreshold: threshold,
+			Parse:             ParseOptions{Encoding: Binary},
+		})
+		assert.NoError(t, err, fmt.Sprintf("threshold %d", threshold))
+
+		res, err := Parse(&text, Int)
+		assert.NoError(t, err, fmt.Sprintf("threshold %d", threshold))
+		assert.Equal(t, 100, len(res), fmt.Sprintf("threshold %d", threshold))
+		assert.Equal(t, Interval[int]{X: 10, Y: 15}, res[0], fmt.Sprintf("threshold %d", threshold))
+	}
+}
@@ -0,0 +1,165 @@
+package intervals
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression is the compression of the input, the result
+// or the temporary files of file mode - see FileOptions.
+type Compression uint8
+
+const (
+	// NoCompression reads and writes data as it is.
+	NoCompression Compression = iota
+	// Gzip compresses with gzip, as files ending in .gz.
+	Gzip
+	// Zstd compresses with Zstandard, as files ending
+	// in .zst.
+	Zstd
+)
+
+const (
+	// zstdWindowSize is the window of the Zstandard
+	// streams written, bounding the memory needed to
+	// read them back.
+	zstdWindowSize = 1 << 20
+
+	// gzipMemory and zstdMemory are the memory used by a
+	// compressor or decompressor beyond its buffers, about
+	// that of the larger of the two.
+	gzipMemory = 1 << 20
+	zstdMemory = 2 << 20
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+func (c Compression) String() string {
+	switch c {
+	case Gzip:
+		return "gzip"
+	case Zstd:
+		return "zstd"
+	default:
+		return "none"
+	}
+}
+
+// memory returns the memory in bytes used by a compressor
+// or decompressor of c beyond its buffers.
+func (c Compression) memory() int64 {
+	switch c {
+	case Gzip:
+		return gzipMemory
+	case Zstd:
+		return zstdMemory
+	default:
+		return 0
+	}
+}
+
+// CompressionOf returns the compression implied by the
+// extension of file name: Gzip for .gz, Zstd for .zst and
+// NoCompression otherwise.
+func CompressionOf(name string) Compression {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".gz":
+		return Gzip
+	case ".zst":
+		return Zstd
+	default:
+		return NoCompression
+	}
+}
+
+// compress returns a writer compressing the data written
+// to it with c into w. Close completes the compressed
+// stream, without closing w.
+func compress(w io.Writer, c Compression) (io.WriteCloser, error) {
+	switch c {
+	case Gzip:
+		return gzip.NewWriter(w), nil
+	case Zstd:
+		return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1), zstd.WithWindowSize(zstdWindowSize))
+	default:
+		return nopWriteCloser{w}, nil
+	}
+}
+
+// compressed calls write with a writer compressing into
+// w with c, and completes the compressed stream once write
+// returns successfully.
+func compressed(w io.Writer, c Compression, write func(w io.Writer) error) error {
+	zw, err := compress(w, c)
+	if err != nil {
+		return err
+	}
+
+	err = write(zw)
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// decompress returns a reader of the data read from r,
+// decompressed if its first bytes are those of a gzip or
+// Zstandard stream, together with the compression found.
+// If c is not NoCompression, non-empty data must be
+// compressed with c. Close releases the decompressor,
+// without closing r.
+func decompress(r io.Reader, c Compression) (io.ReadCloser, Compression, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		return nil, NoCompression, err
+	}
+
+	found := NoCompression
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		found = Gzip
+	case bytes.HasPrefix(magic, zstdMagic):
+		found = Zstd
+	}
+	if c != NoCompression && found != c && len(magic) > 0 {
+		return nil, NoCompression, fmt.Errorf("input is not %s compressed: %w", c, errBadInput)
+	}
+
+	switch found {
+	case Gzip:
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, NoCompression, fmt.Errorf("gzip: %s: %w", err, errBadInput)
+		}
+		return zr, found, nil
+	case Zstd:
+		zr, err := zstd.NewReader(br, zstd.WithDecoderConcurrency(1), zstd.WithDecoderLowmem(true))
+		if err != nil {
+			return nil, NoCompression, err
+		}
+		return zr.IOReadCloser(), found, nil
+	default:
+		return io.NopCloser(br), found, nil
+	}
+}
+
+// nopWriteCloser is a writer with a Close method
+// doing nothing.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
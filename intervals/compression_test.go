@@ -0,0 +1,141 @@
+package intervals
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompressionOf(t *testing.T) {
+	assert.Equal(t, Gzip, CompressionOf("data/in.txt.gz"))
+	assert.Equal(t, Zstd, CompressionOf("in.ZST"))
+	assert.Equal(t, NoCompression, CompressionOf("in.txt"))
+	assert.Equal(t, NoCompression, CompressionOf("gz"))
+}
+
+func TestDecompress(t *testing.T) {
+	testcases := []struct {
+		input    []byte
+		c        Compression
+		expected Compression
+		err      string
+	}{
+		{input: nil, expected: NoCompression},
+		{input: nil, c: Gzip, expected: NoCompression},
+		{input: []byte("[1,2]"), expected: NoCompression},
+		{input: compressText(t, "[1,2]", Gzip), expected: Gzip},
+		{input: compressText(t, "[1,2]", Zstd), expected: Zstd},
+		{input: compressText(t, "[1,2]", Zstd), c: Zstd, expected: Zstd},
+		{input: []byte("[1,2]"), c: Gzip, err: "input is not gzip compressed"},
+		{input: compressText(t, "[1,2]", Gzip), c: Zstd, err: "input is not zstd compressed"},
+		{input: []byte{0x1f, 0x8b, 0}, err: "gzip: unexpected EOF"},
+	}
+
+	for _, test := range testcases {
+		r, c, err := decompress(bytes.NewReader(test.input), test.c)
+		if test.err != "" {
+			assert.ErrorIs(t, err, errBadInput, fmt.Sprintf("testcase: %+v", test))
+			assert.ErrorContains(t, err, test.err, fmt.Sprintf("testcase: %+v", test))
+			continue
+		}
+
+		assert.NoError(t, err, fmt.Sprintf("testcase: %+v", test))
+		assert.Equal(t, test.expected, c, fmt.Sprintf("testcase: %+v", test))
+
+		res, err := ParseWith(r, Int, ParseOptions{})
+		assert.NoError(t, err, fmt.Sprintf("testcase: %+v", test))
+		assert.Equal(t, len(test.input) > 0, len(res) == 1, fmt.Sprintf("testcase: %+v", test))
+		assert.NoError(t, r.Close(), fmt.Sprintf("testcase: %+v", test))
+	}
+}
+
+func TestMergeStreamCompressed(t *testing.T) {
+	var input strings.Builder
+	for k := 0; k < 100; k++ {
+		fmt.Fprintf(&input, "[%d,%d] ", 1000-10*k, 1000-10*k+5)
+	}
+
+	testcases := []struct {
+		input     Compression
+		spill     Compression
+		output    Compression
+		threshold int64
+	}{
+		{input: Gzip, threshold: 0},
+		{input: Zstd, threshold: -1},
+		{spill: Gzip, threshold: -1},
+		{spill: Zstd, output: Zstd, threshold: -1},
+		{input: Gzip, spill: Zstd, output: Gzip, threshold: -1},
+		{output: Gzip, threshold: 0},
+	}
+
+	for _, test := range testcases {
+		opts := FileOptions{
+			ChunkSize:         64,
+			FanIn:             3,
+			InMemoryThreshold: test.threshold,
+			Compression:       test.output,
+			SpillCompression:  test.spill,
+		}
+
+		var w bytes.Buffer
+		err := MergeStream(bytes.NewReader(compressText(t, input.String(), test.input)), &w, Int, opts)
+		assert.NoError(t, err, fmt.Sprintf("testcase: %+v", test))
+
+		r, c, err := decompress(&w, test.output)
+		assert.NoError(t, err, fmt.Sprintf("testcase: %+v", test))
+		assert.Equal(t, test.output, c, fmt.Sprintf("testcase: %+v", test))
+
+		res, err := Parse(r, Int)
+		assert.NoError(t, err, fmt.Sprintf("testcase: %+v", test))
+		assert.Equal(t, 100, len(res), fmt.Sprintf("testcase: %+v", test))
+		assert.Equal(t, Interval[int]{X: 10, Y: 15}, res[0], fmt.Sprintf("testcase: %+v", test))
+	}
+}
+
+func TestMergeFileCompressed(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.txt.gz")
+	err := os.WriteFile(input, compressText(t, "[5,7] [1,3] [2,4]", Gzip), 0o644)
+	assert.NoError(t, err)
+
+	output := filepath.Join(dir, "out.txt.zst")
+	res, err := MergeFile(input, Int, FileOptions{Output: output, Compression: Zstd})
+	assert.NoError(t, err)
+
+	f, err := os.Open(res)
+	assert.NoError(t, err)
+	defer f.Close()
+	r, _, err := decompress(f, Zstd)
+	assert.NoError(t, err)
+	data, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "[1,4] [5,7]\n", string(data))
+
+	// the extension has to match the data
+	misnamed := filepath.Join(dir, "in.txt.zst")
+	err = os.WriteFile(misnamed, compressText(t, "[1,3]", Gzip), 0o644)
+	assert.NoError(t, err)
+	_, err = MergeFile(misnamed, Int, FileOptions{Output: output})
+	assert.ErrorContains(t, err, "input is not zstd compressed")
+
+	_, err = GapsFile(input, Int, FileOptions{Output: output, SpillCompression: Gzip})
+	assert.NoError(t, err)
+}
+
+// compressText returns s compressed with c.
+func compressText(t *testing.T, s string, c Compression) []byte {
+	var b bytes.Buffer
+	err := compressed(&b, c, func(w io.Writer) error {
+		_, err := io.WriteString(w, s)
+		return err
+	})
+	assert.NoError(t, err)
+	return b.Bytes()
+}
@@ -0,0 +1,262 @@
+package intervals
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSVOptions configures reading intervals encoded as CSV,
+// one interval per row.
+type CSVOptions struct {
+	// Comma is the field delimiter, e.g. '\t' for TSV.
+	// ',' is used if it is 0.
+	Comma rune
+
+	// Header makes the first row a header naming the
+	// columns, rather than an interval.
+	Header bool
+
+	// Start and End are the columns of the endpoints:
+	// either a column number, starting at 1, or with
+	// Header the name of a column. The first and second
+	// column are used if they are empty.
+	Start, End string
+
+	// Bounds are the bounds of all intervals read.
+	Bounds Bounds
+}
+
+// comma returns the field delimiter to use.
+func (opts CSVOptions) comma() rune {
+	if opts.Comma == 0 {
+		return ','
+	}
+	return opts.Comma
+}
+
+// Record is an interval read from a row of CSV input,
+// together with the other columns of the row.
+type Record[T any] struct {
+	Interval Interval[T]
+
+	// Payload are the columns of the row other than
+	// the endpoints, in order.
+	Payload []string
+}
+
+// ParseRecords parses intervals like ParseWith, keeping
+// the other columns of each row as the payload of its
+// Record if opts.Encoding is CSV. Records of other
+// encodings have no payload.
+func ParseRecords[T any](r io.Reader, d Domain[T], opts ParseOptions) ([]Record[T], error) {
+	if opts.Encoding != CSV {
+		list, err := ParseWith(r, d, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		res := make([]Record[T], len(list))
+		for k, i := range list {
+			res[k].Interval = i
+		}
+		return res, nil
+	}
+
+	dec := newCSVDecoder(r, d, opts)
+	res := make([]Record[T], 0)
+	for {
+		rec, err := dec.nextRecord(true)
+		if err == io.EOF {
+			return res, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, rec)
+	}
+}
+
+// csvDecoder parses intervals from CSV input one row at
+// a time, without holding more than a single interval
+// in memory.
+type csvDecoder[T any] struct {
+	r    *csv.Reader
+	in   *trackingReader
+	d    Domain[T]
+	opts ParseOptions
+
+	// indices of the endpoint columns, known once
+	// the header has been read
+	start, end int
+	header     bool
+}
+
+// newCSVDecoder returns a csvDecoder reading intervals
+// with endpoints in domain d from r, configured by
+// opts.CSV and validated according to opts.
+func newCSVDecoder[T any](r io.Reader, d Domain[T], opts ParseOptions) *csvDecoder[T] {
+	in := &trackingReader{r: r, pos: startPosition()}
+	cr := csv.NewReader(in)
+	cr.Comma = opts.CSV.comma()
+	// columns are checked when reading the endpoints
+	cr.FieldsPerRecord = -1
+	// payloads are copied out of the rows
+	cr.ReuseRecord = true
+
+	return &csvDecoder[T]{r: cr, in: in, d: d, opts: opts}
+}
+
+// next returns the next valid interval of the input.
+// Intervals dropped according to the parse options are
+// skipped. io.EOF is returned at the end of the input.
+func (dec *csvDecoder[T]) next() (Interval[T], error) {
+	rec, err := dec.nextRecord(false)
+	return rec.Interval, err
+}
+
+// nextRecord returns the next valid interval of the input
+// as next does, together with its payload if payload is
+// true.
+func (dec *csvDecoder[T]) nextRecord(payload bool) (Record[T], error) {
+	if !dec.header {
+		err := dec.readHeader()
+		if err != nil {
+			return Record[T]{}, err
+		}
+		dec.header = true
+	}
+
+	for {
+		start := dec.r.InputOffset()
+		row, err := dec.r.Read()
+		if err == io.EOF {
+			return Record[T]{}, io.EOF
+		}
+
+		var csvErr *csv.ParseError
+		if err != nil && !errors.As(err, &csvErr) {
+			// I/O errors of the reader
+			return Record[T]{}, err
+		}
+
+		// skip the end of the previous row
+		dec.in.advance(start)
+		dec.in.pos.n++
+		pos := dec.in.pos
+		end := dec.r.InputOffset()
+		t := string(dec.in.peek(end))
+		dec.in.advance(end)
+
+		var rec Record[T]
+		ok := false
+		if err == nil {
+			rec, ok, err = dec.parse(row, payload)
+		} else {
+			err = fmt.Errorf("invalid CSV: %s: %w", csvErr.Err, errBadInput)
+		}
+		if err != nil {
+			parseErr := newParseError(pos, t, dec.in.peek(end+snippetContext), err)
+			if !dec.opts.skip(parseErr) {
+				return Record[T]{}, parseErr
+			}
+			continue
+		}
+		if ok {
+			return rec, nil
+		}
+	}
+}
+
+// readHeader reads the header row, if there is one, and
+// resolves the endpoint columns.
+func (dec *csvDecoder[T]) readHeader() error {
+	var header []string
+	if dec.opts.CSV.Header {
+		var err error
+		header, err = dec.r.Read()
+		if err != nil && err != io.EOF {
+			return err
+		}
+		dec.in.advance(dec.r.InputOffset())
+
+		if len(header) > 0 {
+			// spreadsheet exports often begin with a byte order mark
+			header[0] = strings.TrimPrefix(header[0], "\ufeff")
+		}
+	}
+
+	var err error
+	dec.start, err = column(dec.opts.CSV.Start, header, 0)
+	if err != nil {
+		return err
+	}
+
+	dec.end, err = column(dec.opts.CSV.End, header, 1)
+	return err
+}
+
+// column returns the index of column spec, a column number
+// starting at 1 or a name found in header, or def if spec
+// is empty.
+func column(spec string, header []string, def int) (int, error) {
+	if spec == "" {
+		return def, nil
+	}
+
+	if k, err := strconv.Atoi(spec); err == nil {
+		if k < 1 {
+			return 0, fmt.Errorf("column number %d must be at least 1: %w", k, errBadInput)
+		}
+		return k - 1, nil
+	}
+
+	for k, name := range header {
+		if strings.TrimSpace(name) == spec {
+			return k, nil
+		}
+	}
+	return 0, fmt.Errorf("column %q not found in header: %w", spec, errBadInput)
+}
+
+// parse parses and validates the interval in row.
+//
+// It returns the interval, with its payload if payload is
+// true, and true, false if it has to be dropped, or the
+// reason why it is invalid.
+func (dec *csvDecoder[T]) parse(row []string, payload bool) (Record[T], bool, error) {
+	if dec.start >= len(row) || dec.end >= len(row) {
+		return Record[T]{}, false, fmt.Errorf("missing endpoint column, got %d columns: %w", len(row), errBadInput)
+	}
+
+	x, err := parseEndpoint(dec.d, strings.TrimSpace(row[dec.start]))
+	if err != nil {
+		return Record[T]{}, false, err
+	}
+
+	y, err := parseEndpoint(dec.d, strings.TrimSpace(row[dec.end]))
+	if err != nil {
+		return Record[T]{}, false, err
+	}
+
+	i, ok, err := validate(Interval[T]{X: x, Y: y, Bounds: dec.opts.CSV.Bounds}, dec.d, dec.opts)
+	if err != nil || !ok {
+		return Record[T]{}, ok, err
+	}
+	if !payload {
+		return Record[T]{Interval: i}, true, nil
+	}
+
+	rest := make([]string, 0, len(row))
+	for k, field := range row {
+		if k != dec.start && k != dec.end {
+			rest = append(rest, field)
+		}
+	}
+
+	return Record[T]{Interval: i, Payload: rest}, true, nil
+}
@@ -0,0 +1,95 @@
+package intervals
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCSV(t *testing.T) {
+	testcases := []struct {
+		input    string
+		opts     CSVOptions
+		expected string
+		err      string
+	}{
+		{input: "", expected: ""},
+		{input: "1,3\n2,5\n\n7,9\n", expected: "[1,3] [2,5] [7,9]"},
+		{input: "1, 3\r\n 2 ,5", expected: "[1,3] [2,5]"},
+		{input: "1\t3\n2\t5", opts: CSVOptions{Comma: '\t', Bounds: RightOpen}, expected: "[1,3) [2,5)"},
+		{input: "x,1,3,y\n", opts: CSVOptions{Start: "2", End: "3"}, expected: "[1,3]"},
+		{input: "to,from\n3,1\n", opts: CSVOptions{Header: true, Start: "from", End: "to"}, expected: "[1,3]"},
+		{input: "\ufefffrom,to\n1,3\n", opts: CSVOptions{Header: true, Start: "from", End: "to"}, expected: "[1,3]"},
+		{input: "from,to\n", opts: CSVOptions{Header: true}, expected: ""},
+		{input: "1,3\n2,x\n", err: `invalid interval 2 "2,x" at line 2, column 1 (offset 4): failed to convert "x" to endpoint`},
+		{input: "1,3\n2\n", err: "missing endpoint column, got 1 columns"},
+		{input: "1,3\n\"2,5\n", err: "invalid CSV"},
+		{input: "1,3\n", opts: CSVOptions{Start: "from"}, err: `column "from" not found in header`},
+		{input: "1,3\n", opts: CSVOptions{Start: "0"}, err: "column number 0 must be at least 1"},
+	}
+
+	for _, test := range testcases {
+		res, err := ParseWith(strings.NewReader(test.input), Int, ParseOptions{Encoding: CSV, CSV: test.opts})
+		if test.err != "" {
+			assert.ErrorIs(t, err, errBadInput, fmt.Sprintf("testcase: %+v", test))
+			assert.ErrorContains(t, err, test.err, fmt.Sprintf("testcase: %+v", test))
+			continue
+		}
+
+		assert.NoError(t, err, fmt.Sprintf("testcase: %+v", test))
+		assert.Equal(t, test.expected, Format(res, Int), fmt.Sprintf("testcase: %+v", test))
+	}
+}
+
+func TestParseRecords(t *testing.T) {
+	input := "id,start,note,end\na,1,\"x, y\",3\nb,5,,4\nc,7,z,9\n"
+	opts := ParseOptions{
+		Encoding: CSV,
+		CSV:      CSVOptions{Header: true, Start: "start", End: "end"},
+		Reversed: DropReversed,
+	}
+
+	res, err := ParseRecords(strings.NewReader(input), Int, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, []Record[int]{
+		{Interval: Interval[int]{X: 1, Y: 3}, Payload: []string{"a", "x, y"}},
+		{Interval: Interval[int]{X: 7, Y: 9}, Payload: []string{"c", "z"}},
+	}, res)
+
+	// other encodings have no payload
+	res, err = ParseRecords(strings.NewReader("[1,3]"), Int, ParseOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []Record[int]{{Interval: Interval[int]{X: 1, Y: 3}}}, res)
+}
+
+func TestMergeStreamCSV(t *testing.T) {
+	var input bytes.Buffer
+	input.WriteString("name\tfrom\tto\n")
+	for k := 0; k < 100; k++ {
+		fmt.Fprintf(&input, "n%d\t%d\t%d\n", k, 1000-10*k, 1000-10*k+5)
+	}
+
+	for _, threshold := range []int64{0, -1} {
+		opts := FileOptions{
+			ChunkSize:         64,
+			FanIn:             3,
+			InMemoryThreshold: threshold,
+			Parse: ParseOptions{
+				Encoding: CSV,
+				CSV:      CSVOptions{Comma: '\t', Header: true, Start: "from", End: "to"},
+			},
+		}
+
+		var w bytes.Buffer
+		err := MergeStream(bytes.NewReader(input.Bytes()), &w, Int, opts)
+		assert.NoError(t, err, fmt.Sprintf("threshold %d", threshold))
+
+		res, err := Parse(&w, Int)
+		assert.NoError(t, err, fmt.Sprintf("threshold %d", threshold))
+		assert.Equal(t, 100, len(res), fmt.Sprintf("threshold %d", threshold))
+		assert.Equal(t, Interval[int]{X: 10, Y: 15}, res[0], fmt.Sprintf("threshold %d", threshold))
+	}
+}
@@ -0,0 +1,95 @@
+package intervals
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// spillFactor is the number of bytes of temporary files
+// estimated per byte of input: while a merge pass writes
+// a new run, the runs it reads from still exist.
+const spillFactor = 2
+
+var errNoSpace = errors.New("not enough free disk space")
+
+// estimateSpill returns the estimated size in bytes of the
+// temporary files written while processing inputs of the
+// given sizes. Unknown sizes are passed as 0.
+func estimateSpill(sizes ...int64) int64 {
+	var spill int64
+	for _, size := range sizes {
+		spill += spillFactor * size
+	}
+	return spill
+}
+
+// fileSize returns the size of the regular file at
+// filePath, or 0 if it cannot be determined.
+func fileSize(filePath string) int64 {
+	fi, err := os.Stat(filePath)
+	if err != nil || !fi.Mode().IsRegular() {
+		return 0
+	}
+	return fi.Size()
+}
+
+// inputSize returns the size of the input file at
+// filePath as fileSize does, or 0 if it is compressed:
+// the size of its data is unknown.
+func inputSize(filePath string) int64 {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	r, c, err := decompress(f, NoCompression)
+	if err != nil || c != NoCompression {
+		return 0
+	}
+	r.Close()
+
+	return fileSize(filePath)
+}
+
+// readerSize returns the size of the data read from r if
+// it is a regular file, or 0 if it cannot be determined,
+// e.g. for a pipe.
+func readerSize(r io.Reader) int64 {
+	f, ok := r.(*os.File)
+	if !ok {
+		return 0
+	}
+
+	fi, err := f.Stat()
+	if err != nil || !fi.Mode().IsRegular() {
+		return 0
+	}
+
+	pos, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0
+	}
+	return fi.Size() - pos
+}
+
+// checkFreeSpace returns an error if the filesystem of dir
+// has less than need bytes available.
+//
+// The check is best effort: it passes if need is not
+// positive, or if the free space cannot be determined on
+// this platform or for dir.
+func checkFreeSpace(dir string, need int64) error {
+	if need <= 0 {
+		return nil
+	}
+
+	free, ok := freeSpace(dir)
+	if !ok || free >= uint64(need) {
+		return nil
+	}
+
+	return fmt.Errorf("%w in %q: about %d bytes needed for temporary files, %d available", errNoSpace, dir, need, free)
+}
@@ -0,0 +1,9 @@
+//go:build !(linux || darwin || freebsd)
+
+package intervals
+
+// freeSpace reports that the free space cannot be
+// determined on this platform.
+func freeSpace(dir string) (uint64, bool) {
+	return 0, false
+}
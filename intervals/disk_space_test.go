@@ -0,0 +1,50 @@
+package intervals
+
+import (
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateSpill(t *testing.T) {
+	assert.Equal(t, int64(0), estimateSpill())
+	assert.Equal(t, int64(0), estimateSpill(0))
+	assert.Equal(t, int64(2*100+2*50), estimateSpill(100, 50))
+}
+
+func TestReaderSize(t *testing.T) {
+	f, err := os.Open("../data/simple_example.txt")
+	assert.NoError(t, err)
+	defer f.Close()
+
+	size := fileSize("../data/simple_example.txt")
+	assert.Greater(t, size, int64(0))
+	assert.Equal(t, size, readerSize(f))
+
+	_, err = f.Seek(3, io.SeekStart)
+	assert.NoError(t, err)
+	assert.Equal(t, size-3, readerSize(f))
+
+	r, w, err := os.Pipe()
+	assert.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+	assert.Equal(t, int64(0), readerSize(r))
+
+	assert.Equal(t, int64(0), fileSize(filepath.Join(t.TempDir(), "missing.txt")))
+}
+
+func TestCheckFreeSpace(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, checkFreeSpace(dir, 0))
+	assert.NoError(t, checkFreeSpace(dir, 1))
+
+	if _, ok := freeSpace(dir); !ok {
+		t.Skip("free space cannot be determined on this platform")
+	}
+	assert.ErrorIs(t, checkFreeSpace(dir, math.MaxInt64), errNoSpace)
+}
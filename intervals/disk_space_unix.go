@@ -0,0 +1,20 @@
+//go:build linux || darwin || freebsd
+
+package intervals
+
+import (
+	"syscall"
+)
+
+// freeSpace returns the number of bytes available to
+// unprivileged users on the filesystem of dir, and
+// whether it could be determined.
+func freeSpace(dir string) (uint64, bool) {
+	var st syscall.Statfs_t
+	err := syscall.Statfs(dir, &st)
+	if err != nil {
+		return 0, false
+	}
+
+	return uint64(st.Bavail) * uint64(st.Bsize), true
+}
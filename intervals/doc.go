@@ -0,0 +1,40 @@
+// Package intervals merges lists of overlapping intervals.
+//
+// Intervals are generic over their endpoint type. A Domain
+// describes how endpoints are compared, parsed and formatted;
+// Int, Int64, Uint64, Float64, Time and BigInt are predefined.
+//
+// Lists small enough to fit in memory can be parsed with Parse,
+// merged with Merge and written back with Format:
+//
+//	list, err := intervals.Parse(strings.NewReader("[25,30] [2,19] [14, 23] [4,8]"), intervals.Int)
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Println(intervals.Format(intervals.Merge(list, intervals.Int), intervals.Int)) // [2,23] [25,30]
+//
+// Merged lists can be combined with Intersect, Subtract and
+// SymmetricDifference. Gaps and Complement return the ranges
+// a merged list does not cover.
+//
+// ParseWith and FormatWith also read and write intervals
+// encoded as JSON, one per line or in a compact binary
+// format, and ParseWith reads them from the rows of CSV
+// input - see Encoding. ParseRecords keeps the other
+// columns of each row.
+//
+// Index and Tree answer repeated point and range queries:
+// Index on merged lists, Tree on lists of overlapping intervals.
+// IntervalSet keeps a merged list up to date while intervals
+// are inserted and removed one at a time.
+//
+// Lists that do not fit in memory can be merged from a file
+// with MergeFile, or from any stream with MergeStream, which
+// process the input in chunks and spill intermediate results
+// to disk. IntersectFiles, SubtractFiles,
+// SymmetricDifferenceFiles, GapsFile and ComplementFile
+// process files the same way. Input compressed with gzip or
+// Zstandard is decompressed while reading, and the result
+// and intermediate files can be compressed - see
+// FileOptions.
+package intervals
@@ -0,0 +1,253 @@
+package intervals
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"time"
+
+	"golang.org/x/exp/constraints"
+)
+
+// Domain describes the endpoint type T of an interval:
+// how two endpoints compare to each other and how they are
+// converted from and to their text representation.
+//
+// Only Compare is needed to merge intervals in memory.
+// Parse and Format are needed to read and write intervals,
+// e.g. with Parse, Format or MergeFile. Add and Discrete
+// are only needed for adjacency and gap merging - see
+// MergeOptions.
+type Domain[T any] struct {
+	// Compare returns a negative number if a < b, zero if
+	// a == b and a positive number if a > b.
+	Compare func(a, b T) int
+
+	// Parse converts the text representation of an endpoint,
+	// with surrounding whitespace already removed, into T.
+	// Errors for values out of the range of T should wrap
+	// strconv.ErrRange, as the strconv functions do.
+	Parse func(s string) (T, error)
+
+	// Format converts an endpoint into its text representation.
+	// The result must not contain brackets, commas or whitespace.
+	Format func(v T) string
+
+	// Add returns v moved by n units of the domain. The result
+	// must saturate at the limits of T instead of wrapping around.
+	Add func(v T, n int64) T
+
+	// Discrete reports whether there are no values between
+	// v and Add(v, 1), as is the case for integers.
+	Discrete bool
+
+	// binary stores endpoints compactly in the Binary
+	// encoding. Domains without it are stored as text.
+	binary *binaryCodec[T]
+}
+
+var (
+	// Int is the domain of int endpoints.
+	Int = Domain[int]{
+		Compare: compareOrdered[int],
+		Parse:   strconv.Atoi,
+		Format:  strconv.Itoa,
+		Add: func(v int, n int64) int {
+			res := addInt64(int64(v), n)
+			// saturate on platforms with 32 bit int
+			switch {
+			case res > math.MaxInt:
+				return math.MaxInt
+			case res < math.MinInt:
+				return math.MinInt
+			default:
+				return int(res)
+			}
+		},
+		Discrete: true,
+		binary: &binaryCodec[int]{
+			width: varintWidth,
+			bits: func(v int) uint64 {
+				return uint64(v)
+			},
+			fromBits: func(b uint64) (int, error) {
+				// runs written on platforms with 64 bit int
+				// may not fit
+				if int64(int(int64(b))) != int64(b) {
+					return 0, ErrOutOfRange
+				}
+				return int(int64(b)), nil
+			},
+		},
+	}
+
+	// Int64 is the domain of int64 endpoints.
+	Int64 = Domain[int64]{
+		Compare: compareOrdered[int64],
+		Parse: func(s string) (int64, error) {
+			return strconv.ParseInt(s, 10, 64)
+		},
+		Format: func(v int64) string {
+			return strconv.FormatInt(v, 10)
+		},
+		Add:      addInt64,
+		Discrete: true,
+		binary: &binaryCodec[int64]{
+			width: varintWidth,
+			bits: func(v int64) uint64 {
+				return uint64(v)
+			},
+			fromBits: func(b uint64) (int64, error) {
+				return int64(b), nil
+			},
+		},
+	}
+
+	// Uint64 is the domain of uint64 endpoints, e.g. offsets.
+	Uint64 = Domain[uint64]{
+		Compare: compareOrdered[uint64],
+		Parse: func(s string) (uint64, error) {
+			return strconv.ParseUint(s, 10, 64)
+		},
+		Format: func(v uint64) string {
+			return strconv.FormatUint(v, 10)
+		},
+		Add:      addUint64,
+		Discrete: true,
+		binary: &binaryCodec[uint64]{
+			width: varintWidth,
+			bits: func(v uint64) uint64 {
+				return v
+			},
+			fromBits: func(b uint64) (uint64, error) {
+				return b, nil
+			},
+		},
+	}
+
+	// Float64 is the domain of float64 endpoints.
+	// NaN is rejected, as it is not ordered.
+	Float64 = Domain[float64]{
+		Compare: compareOrdered[float64],
+		Parse: func(s string) (float64, error) {
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return 0, err
+			}
+			if math.IsNaN(v) {
+				return 0, errNaN
+			}
+			return v, nil
+		},
+		Format: func(v float64) string {
+			return strconv.FormatFloat(v, 'g', -1, 64)
+		},
+		Add: func(v float64, n int64) float64 {
+			return v + float64(n)
+		},
+		binary: &binaryCodec[float64]{
+			width: 8,
+			bits:  math.Float64bits,
+			fromBits: func(b uint64) (float64, error) {
+				v := math.Float64frombits(b)
+				if math.IsNaN(v) {
+					return 0, errNaN
+				}
+				return v, nil
+			},
+		},
+	}
+
+	// Time is the domain of time.Time endpoints, written
+	// in RFC 3339 format, e.g. 2006-01-02T15:04:05Z.
+	Time = Domain[time.Time]{
+		Compare: func(a, b time.Time) int {
+			return a.Compare(b)
+		},
+		Parse: func(s string) (time.Time, error) {
+			return time.Parse(time.RFC3339Nano, s)
+		},
+		Format: func(v time.Time) string {
+			return v.Format(time.RFC3339Nano)
+		},
+		// one unit is a nanosecond
+		Add: func(v time.Time, n int64) time.Time {
+			return v.Add(time.Duration(n))
+		},
+	}
+
+	// BigInt is the domain of *big.Int endpoints: integers
+	// of arbitrary size, e.g. beyond the range of Int64.
+	// Endpoints are never modified, and may be shared
+	// between intervals.
+	//
+	// The memory limit of FileOptions.MaxMemory only
+	// accounts for the pointers, not for the digits.
+	BigInt = Domain[*big.Int]{
+		Compare: func(a, b *big.Int) int {
+			return a.Cmp(b)
+		},
+		Parse: func(s string) (*big.Int, error) {
+			v, ok := new(big.Int).SetString(s, 10)
+			if !ok {
+				return nil, fmt.Errorf("invalid integer %q", s)
+			}
+			return v, nil
+		},
+		Format: func(v *big.Int) string {
+			return v.String()
+		},
+		Add: func(v *big.Int, n int64) *big.Int {
+			return new(big.Int).Add(v, big.NewInt(n))
+		},
+		Discrete: true,
+	}
+
+	errNaN = errors.New("NaN is not a valid endpoint")
+)
+
+// addInt64 returns v+n, saturating at math.MinInt64
+// and math.MaxInt64.
+func addInt64(v int64, n int64) int64 {
+	switch {
+	case n > 0 && v > math.MaxInt64-n:
+		return math.MaxInt64
+	case n < 0 && v < math.MinInt64-n:
+		return math.MinInt64
+	default:
+		return v + n
+	}
+}
+
+// addUint64 returns v+n, saturating at 0 and
+// math.MaxUint64.
+func addUint64(v uint64, n int64) uint64 {
+	if n < 0 {
+		// n == math.MinInt64 cannot be negated as an int64
+		m := uint64(-(n + 1)) + 1
+		if v < m {
+			return 0
+		}
+		return v - m
+	}
+
+	if v > math.MaxUint64-uint64(n) {
+		return math.MaxUint64
+	}
+	return v + uint64(n)
+}
+
+// compareOrdered compares two values of an ordered type
+// using the built-in comparison operators.
+func compareOrdered[T constraints.Ordered](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
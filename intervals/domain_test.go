@@ -0,0 +1,119 @@
+package intervals
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeFloat64(t *testing.T) {
+	list, err := Parse(strings.NewReader("[2.5,3] [0.5, 1.25] [1,2.5] [-1e3,-0.5]"), Float64)
+	assert.NoError(t, err)
+	assert.Equal(t, "[-1000,-0.5] [0.5,3]", Format(Merge(list, Float64), Float64))
+
+	_, err = Parse(strings.NewReader("[NaN,1]"), Float64)
+	assert.ErrorIs(t, err, errBadInput)
+}
+
+func TestMergeUint64(t *testing.T) {
+	list, err := Parse(strings.NewReader("[18446744073709551610,18446744073709551615] [0,1] [1,2]"), Uint64)
+	assert.NoError(t, err)
+	assert.Equal(t, "[0,2] [18446744073709551610,18446744073709551615]", Format(Merge(list, Uint64), Uint64))
+
+	_, err = Parse(strings.NewReader("[-1,1]"), Uint64)
+	assert.ErrorIs(t, err, errBadInput)
+}
+
+func TestMergeTime(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2023, 8, 1, hour, 0, 0, 0, time.UTC)
+	}
+
+	list := []Interval[time.Time]{
+		{X: at(13), Y: at(15)},
+		{X: at(8), Y: at(10)},
+		{X: at(9), Y: at(12)},
+	}
+	expected := []Interval[time.Time]{
+		{X: at(8), Y: at(12)},
+		{X: at(13), Y: at(15)},
+	}
+	assert.Equal(t, expected, Merge(list, Time))
+
+	s := "[2023-08-01T08:00:00Z,2023-08-01T12:00:00Z] [2023-08-01T13:00:00Z,2023-08-01T15:00:00Z]"
+	parsed, err := Parse(strings.NewReader(s), Time)
+	assert.NoError(t, err)
+	assert.Equal(t, s, Format(parsed, Time))
+}
+
+func TestCompareOrdered(t *testing.T) {
+	testcases := []struct {
+		a, b     float64
+		expected int
+	}{
+		{a: 1, b: 2, expected: -1},
+		{a: 2, b: 1, expected: 1},
+		{a: 1, b: 1, expected: 0},
+		{a: math.Inf(-1), b: 0, expected: -1},
+	}
+
+	for _, test := range testcases {
+		assert.Equal(t, test.expected, compareOrdered(test.a, test.b), fmt.Sprintf("testcase: %+v", test))
+	}
+}
+
+func TestAdd(t *testing.T) {
+	assert.Equal(t, int64(3), addInt64(1, 2))
+	assert.Equal(t, int64(math.MaxInt64), addInt64(math.MaxInt64-1, 2))
+	assert.Equal(t, int64(math.MinInt64), addInt64(math.MinInt64+1, -2))
+
+	assert.Equal(t, uint64(3), addUint64(1, 2))
+	assert.Equal(t, uint64(0), addUint64(1, -2))
+	assert.Equal(t, uint64(math.MaxInt64), addUint64(math.MaxUint64, math.MinInt64))
+	assert.Equal(t, uint64(math.MaxUint64), addUint64(math.MaxUint64-1, 2))
+}
+
+func TestMergeBigInt(t *testing.T) {
+	list, err := Parse(strings.NewReader("[9223372036854775808,18446744073709551616] [-99999999999999999999,-1] [18446744073709551617,18446744073709551620]"), BigInt)
+	assert.NoError(t, err)
+	assert.Equal(t, "[-99999999999999999999,-1] [9223372036854775808,18446744073709551616] [18446744073709551617,18446744073709551620]", Format(Merge(list, BigInt), BigInt))
+
+	merged, err := MergeWith(list, BigInt, MergeOptions{Adjacent: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "[-99999999999999999999,-1] [9223372036854775808,18446744073709551620]", Format(merged, BigInt))
+
+	_, err = Parse(strings.NewReader("[1.5,2]"), BigInt)
+	assert.ErrorIs(t, err, errBadInput)
+}
+
+func TestParseOutOfRange(t *testing.T) {
+	testcases := []struct {
+		input      string
+		valid      bool
+		outOfRange bool
+	}{
+		{input: "[1,9223372036854775808]", outOfRange: true},
+		{input: "[-9223372036854775809,1]", outOfRange: true},
+		{input: "[-9223372036854775808,9223372036854775807]", valid: true},
+		{input: "[1,x]"},
+	}
+
+	for _, test := range testcases {
+		_, err := Parse(strings.NewReader(test.input), Int64)
+		if test.valid {
+			assert.NoError(t, err, fmt.Sprintf("testcase: %+v", test))
+			continue
+		}
+
+		assert.ErrorIs(t, err, errBadInput, fmt.Sprintf("testcase: %+v", test))
+		assert.Equal(t, test.outOfRange, errors.Is(err, ErrOutOfRange), fmt.Sprintf("testcase: %+v", test))
+	}
+
+	_, err := Parse(strings.NewReader("[0,1e400]"), Float64)
+	assert.ErrorIs(t, err, ErrOutOfRange)
+}
@@ -0,0 +1,153 @@
+package intervals
+
+import (
+	"bufio"
+	"io"
+)
+
+// Encoding is the representation of an interval list
+// read or written.
+type Encoding uint8
+
+const (
+	// Text writes intervals with brackets for included
+	// endpoints and parentheses for excluded ones,
+	// separated by whitespace: [1,2] (3,4].
+	Text Encoding = iota
+	// JSONArrays writes a JSON array of intervals, each
+	// an array of its endpoints: [[1,2],[3,4,"(]"]].
+	// Bounds other than closed follow as a third element.
+	JSONArrays
+	// JSONObjects writes a JSON array of intervals, each
+	// an object: [{"start":1,"end":2}]. Bounds other than
+	// closed are given as "bounds", e.g. "(]".
+	JSONObjects
+	// CSV reads one interval per row of CSV input, or of
+	// input with another delimiter such as TSV, configured
+	// by ParseOptions.CSV. It is an input encoding only:
+	// results are written as Text instead. Columns other
+	// than the endpoints are ignored, except by
+	// ParseRecords, which returns them as payload.
+	CSV
+	// Lines writes intervals like Text, one per line. It
+	// is read like Text, but split into chunks at newlines
+	// in file mode.
+	Lines
+	// NDJSON writes intervals as JSON objects, one per
+	// line: {"start":1,"end":2}. Each line read may hold an
+	// interval in either JSON encoding. Unlike a JSON
+	// array, the lines are parsed concurrently in file mode.
+	NDJSON
+	// Binary writes intervals in a compact binary format:
+	// a header with the number of intervals, followed by
+	// integer endpoints as varint deltas, Float64 endpoints
+	// with their 64 bits and others as text. It is the
+	// format of the runs of file mode. Binary input is read
+	// sequentially in file mode, and its results are not
+	// terminated by a newline.
+	Binary
+)
+
+// lineOriented reports whether e holds one interval per
+// line, so that input can be split at any newline.
+func (e Encoding) lineOriented() bool {
+	return e == Lines || e == NDJSON
+}
+
+// decoded reports whether input in encoding e is decoded
+// before it is split into chunks, as it cannot be split
+// as text.
+func (e Encoding) decoded() bool {
+	switch e {
+	case JSONArrays, JSONObjects, CSV, Binary:
+		return true
+	default:
+		return false
+	}
+}
+
+// FormatOptions configures FormatWith. The zero value
+// writes the Text encoding.
+type FormatOptions struct {
+	Encoding Encoding
+}
+
+// encoder writes intervals one at a time, without
+// holding the list in memory.
+type encoder[T any] interface {
+	// encode writes interval i.
+	encode(i Interval[T]) error
+
+	// flush completes the output after the last interval
+	// and writes any buffered data to the underlying writer.
+	flush() error
+}
+
+// newEncoder returns an encoder writing intervals with
+// endpoints in domain d to w, in the encoding of opts.
+// Output is buffered: flush has to be called after the
+// last interval.
+func newEncoder[T any](w io.Writer, d Domain[T], opts FormatOptions) encoder[T] {
+	switch opts.Encoding {
+	case JSONArrays, JSONObjects:
+		return &jsonEncoder[T]{w: bufio.NewWriter(w), d: d, objects: opts.Encoding == JSONObjects}
+	case NDJSON:
+		return &jsonEncoder[T]{w: bufio.NewWriter(w), d: d, objects: true, lines: true}
+	case Lines:
+		return &textEncoder[T]{w: bufio.NewWriter(w), d: d, sep: '\n'}
+	case Binary:
+		return newBinaryEncoder(w, d, unknownCount)
+	default:
+		return &textEncoder[T]{w: bufio.NewWriter(w), d: d, sep: ' '}
+	}
+}
+
+// newListEncoder returns an encoder as newEncoder does,
+// for a list of n intervals known in advance: the Binary
+// encoding writes the number to its header.
+func newListEncoder[T any](w io.Writer, d Domain[T], opts FormatOptions, n int) encoder[T] {
+	if opts.Encoding == Binary {
+		return newBinaryEncoder(w, d, uint64(n))
+	}
+	return newEncoder(w, d, opts)
+}
+
+// newSource returns a source reading intervals with
+// endpoints in domain d from r, in the encoding of opts
+// and validated according to opts.
+func newSource[T any](r io.Reader, d Domain[T], opts ParseOptions) source[T] {
+	switch opts.Encoding {
+	case JSONArrays, JSONObjects:
+		return newJSONDecoder(r, d, opts)
+	case CSV:
+		return newCSVDecoder(r, d, opts)
+	case Binary:
+		return newBinaryDecoder(r, d, opts)
+	default:
+		return newSourceAt(r, d, opts, startPosition())
+	}
+}
+
+// newSourceAt returns a source as newSource does, for
+// input that starts at pos within a larger input, e.g. a
+// chunk of a file. Only Text, Lines and NDJSON can be
+// read from within a larger input.
+func newSourceAt[T any](r io.Reader, d Domain[T], opts ParseOptions, pos position) source[T] {
+	if opts.Encoding == NDJSON {
+		return newNDJSONDecoder(r, d, opts, pos)
+	}
+	return newDecoderAt(r, d, opts, pos)
+}
+
+// boundsText returns the brackets written for bounds b,
+// e.g. "(]" for LeftOpen.
+func boundsText(b Bounds) string {
+	opening, closing := "[", "]"
+	if b&LeftOpen != 0 {
+		opening = "("
+	}
+	if b&RightOpen != 0 {
+		closing = ")"
+	}
+	return opening + closing
+}
@@ -0,0 +1,1108 @@
+package intervals
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+const (
+	// 2 x 20 digit integers, the widest int64 and uint64
+	// endpoints, + enough space for brackets, comma and
+	// whitespace characters. Chunks of at least this size
+	// fit any interval of the fixed size domains; intervals
+	// of BigInt need chunks as large as their text.
+	minBufferSize = 20*2 + 24
+
+	tempDirPattern = "tmp.*"
+	resultFileName = "result.txt"
+
+	// DefaultChunkSize is the chunk size used by MergeFile
+	// when FileOptions.ChunkSize is not set: 1MB.
+	DefaultChunkSize = 1024 * 1024
+
+	// DefaultOutput is the path of the result file written
+	// by MergeFile when FileOptions.Output is not set.
+	DefaultOutput = resultFileName
+
+	// DefaultInMemoryThreshold is the size of the inputs
+	// merged in memory when FileOptions.InMemoryThreshold
+	// is not set: 1MB.
+	DefaultInMemoryThreshold = 1024 * 1024
+
+	// DefaultFanIn is the number of sorted runs merged at
+	// once by MergeFile when FileOptions.FanIn is not set.
+	DefaultFanIn = 64
+)
+
+var errOutputExists = errors.New("output file already exists")
+
+// FileOptions configures MergeFile.
+type FileOptions struct {
+	// Output is the path of the result file. It is written
+	// atomically: readers see either the previous file or
+	// the complete result. DefaultOutput is used if it is
+	// empty.
+	Output string
+
+	// NoClobber makes processing fail instead of replacing
+	// an existing file at Output.
+	NoClobber bool
+
+	// InMemoryThreshold is the size in bytes up to which
+	// inputs are merged in memory, without temporary files.
+	// Larger inputs are spilled to disk. With MaxMemory, it
+	// is reduced to fit the budget. DefaultInMemoryThreshold
+	// is used if it is 0; if it is negative, inputs are
+	// always spilled.
+	InMemoryThreshold int64
+
+	// MaxMemory is the budget in bytes for the memory used
+	// while processing, apart from the Go runtime itself.
+	// ChunkSize, Workers and FanIn are reduced as needed to
+	// stay within it, and an error is returned if it is too
+	// small to make progress. Without a budget, memory use
+	// is only bounded by those options.
+	MaxMemory int64
+
+	// TempDir is the directory in which the temporary files
+	// are created. Before processing, it is checked to have
+	// enough free space for them, estimated from the input
+	// size. os.TempDir, which honors TMPDIR, is used if it
+	// is empty.
+	TempDir string
+
+	// ChunkSize is the maximum size in bytes of each
+	// chunk the input file is split into. Chunks are
+	// processed in memory - see Workers. If it is not
+	// positive, DefaultChunkSize is used, or with MaxMemory
+	// the largest size fitting the budget.
+	ChunkSize int
+
+	// FanIn is the maximum number of sorted runs merged
+	// at once. Each run is read through its own buffer of
+	// a few KB, so it bounds the memory used while merging.
+	// DefaultFanIn is used if it is less than 2.
+	FanIn int
+
+	// Workers is the number of chunks parsed, sorted and
+	// merged concurrently. Each worker holds one chunk in
+	// memory. GOMAXPROCS is used if it is not positive.
+	Workers int
+
+	// Parse configures how invalid intervals in the
+	// input are handled - see ParseWith.
+	Parse ParseOptions
+
+	// Merge configures which intervals are considered
+	// connected - see MergeWith.
+	Merge MergeOptions
+
+	// Format configures the encoding of the result - see
+	// FormatWith. The encoding of the input is configured
+	// by Parse.
+	Format FormatOptions
+
+	// Compression is the compression of the result.
+	// Compressed input is detected from its first bytes
+	// and decompressed while reading, regardless of it.
+	Compression Compression
+
+	// SpillCompression is the compression of the temporary
+	// files, trading CPU time for disk space. Zstd is the
+	// faster choice.
+	SpillCompression Compression
+}
+
+// chunkSize returns the chunk size to use.
+func (opts FileOptions) chunkSize() int {
+	if opts.ChunkSize <= 0 {
+		return DefaultChunkSize
+	}
+	return opts.ChunkSize
+}
+
+// output returns the path of the result file.
+func (opts FileOptions) output() string {
+	if opts.Output == "" {
+		return DefaultOutput
+	}
+	return opts.Output
+}
+
+// tempDir returns the directory for temporary files.
+func (opts FileOptions) tempDir() string {
+	if opts.TempDir == "" {
+		return os.TempDir()
+	}
+	return opts.TempDir
+}
+
+// inMemoryThreshold returns the size up to which inputs of
+// intervals of intervalSize bytes are merged in memory, or
+// -1 if they are always spilled.
+func (opts FileOptions) inMemoryThreshold(intervalSize int) int64 {
+	threshold := opts.InMemoryThreshold
+	if threshold == 0 {
+		threshold = DefaultInMemoryThreshold
+	}
+	if threshold < 0 {
+		return -1
+	}
+
+	// the input, the intervals parsed from it and an
+	// encoder: as a chunk processed by a single worker
+	if opts.MaxMemory > 0 {
+		if max := maxChunkSize(opts.MaxMemory, 1, intervalSize, opts.Compression, false); max < threshold {
+			threshold = max
+		}
+	}
+	return threshold
+}
+
+// workers returns the number of workers to use.
+func (opts FileOptions) workers() int {
+	if opts.Workers <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return opts.Workers
+}
+
+// fanIn returns the fan-in to use.
+func (opts FileOptions) fanIn() int {
+	if opts.FanIn < 2 {
+		return DefaultFanIn
+	}
+	return opts.FanIn
+}
+
+// MergeFile merges the intervals contained in the file at
+// filePath, with endpoints in domain d.
+//
+// Files of at most opts.InMemoryThreshold bytes are merged
+// in memory, as Merge does. Larger files are merged without
+// holding the whole list in memory, by an external merge
+// sort:
+//
+//   - split file in chunks of opts.ChunkSize bytes.
+//   - sort and merge each chunk in memory and write it
+//     to a temporary file: a sorted run.
+//   - merge up to opts.FanIn runs at a time into a new run,
+//     streaming their intervals through a heap.
+//   - continue until a single run is left.
+//
+// Peak memory use is bounded by the chunk size while
+// splitting, and by the fan-in while merging, regardless
+// of how the intervals are laid out in the file. The
+// result is the same either way.
+//
+// Upon success, the result will be written to the file
+// at opts.Output, and its path returned, together with
+// a nil error.
+// Input parsing errors or I/O errors will interrupt
+// processing and be returned accordingly with an empty string.
+func MergeFile[T any](filePath string, d Domain[T], opts FileOptions) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return mergeReader(file, CompressionOf(filePath), d, opts)
+}
+
+// MergeReader merges the intervals read from r as
+// MergeStream does, and writes the result to the file
+// at opts.Output as MergeFile does.
+func MergeReader[T any](r io.Reader, d Domain[T], opts FileOptions) (string, error) {
+	return mergeReader(r, NoCompression, d, opts)
+}
+
+// mergeReader merges the intervals read from r as
+// MergeReader does. If c is not NoCompression, the
+// input must be compressed with c.
+func mergeReader[T any](r io.Reader, c Compression, d Domain[T], opts FileOptions) (string, error) {
+	err := checkOutput(opts)
+	if err != nil {
+		return "", err
+	}
+
+	in, spill, err := openInput(r, c)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	r = in
+
+	data, small, err := readSmall[T](r, opts)
+	if err != nil {
+		return "", err
+	}
+	if small {
+		return writeResult(opts, func(w io.Writer) error {
+			return mergeInMemory(data, w, d, opts)
+		})
+	}
+
+	r = io.MultiReader(bytes.NewReader(data), r)
+	return processInTempDir(d, opts, spill, func(tempDir string) (*os.File, error) {
+		return mergeStream(r, tempDir, d, opts)
+	})
+}
+
+// MergeStream merges the intervals read from r, with
+// endpoints in domain d, as MergeFile does, and writes
+// the result to w, terminated by a newline and compressed
+// according to opts.Compression. The input is read once
+// from start to end, so r need not be seekable, e.g.
+// standard input. Compressed input is decompressed.
+//
+// MergeStream suits inputs of any size: small inputs are
+// merged in memory, and only inputs larger than
+// opts.InMemoryThreshold are spilled to disk.
+//
+// Input parsing errors or I/O errors will interrupt
+// processing and be returned accordingly.
+func MergeStream[T any](r io.Reader, w io.Writer, d Domain[T], opts FileOptions) error {
+	in, spill, err := openInput(r, NoCompression)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	r = in
+
+	data, small, err := readSmall[T](r, opts)
+	if err != nil {
+		return err
+	}
+	if small {
+		return compressed(w, opts.Compression, func(w io.Writer) error {
+			return mergeInMemory(data, w, d, opts)
+		})
+	}
+
+	r = io.MultiReader(bytes.NewReader(data), r)
+	return withTempDir(d, opts, spill, func(tempDir string) error {
+		f, err := mergeStream(r, tempDir, d, opts)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		return compressed(w, opts.Compression, func(w io.Writer) error {
+			return copyResult(w, f, d, opts)
+		})
+	})
+}
+
+// openInput returns a reader of the input read from r,
+// decompressed as described in decompress, and the
+// estimated size of its temporary files - see
+// estimateSpill. It is the responsibility of the caller
+// to close the reader.
+func openInput(r io.Reader, c Compression) (io.ReadCloser, int64, error) {
+	spill := estimateSpill(readerSize(r))
+
+	rc, found, err := decompress(r, c)
+	if err != nil {
+		return nil, 0, err
+	}
+	if found != NoCompression {
+		// the size of the decompressed input is unknown
+		spill = 0
+	}
+	return rc, spill, nil
+}
+
+// readSmall reads the input from r if it is at most
+// opts.InMemoryThreshold bytes long, and reports whether
+// it is. Otherwise, the bytes read so far are returned
+// and the rest of the input remains in r.
+func readSmall[T any](r io.Reader, opts FileOptions) ([]byte, bool, error) {
+	size := intervalSize[T]()
+
+	// report a budget too small before any processing
+	_, err := opts.limits(size)
+	if err != nil {
+		return nil, false, err
+	}
+
+	threshold := opts.inMemoryThreshold(size)
+	if threshold < 0 {
+		return nil, false, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, threshold+1))
+	if err != nil {
+		return nil, false, err
+	}
+
+	return data, int64(len(data)) <= threshold, nil
+}
+
+// mergeInMemory parses, validates and merges the intervals
+// in data according to opts and writes them to w,
+// terminated as by writeEnd, as the external merge sort of
+// MergeFile does.
+func mergeInMemory[T any](data []byte, w io.Writer, d Domain[T], opts FileOptions) error {
+	list, err := ParseWith(bytes.NewReader(data), d, opts.Parse)
+	if err != nil {
+		return err
+	}
+
+	list, err = MergeWith(list, d, opts.Merge)
+	if err != nil {
+		return err
+	}
+
+	enc := newListEncoder(w, d, opts.Format, len(list))
+	for _, i := range list {
+		err = enc.encode(i)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = enc.flush()
+	if err != nil {
+		return err
+	}
+
+	return writeEnd(w, opts.Format)
+}
+
+// processInTempDir runs process in a new temporary directory
+// as described in withTempDir. The file returned by process
+// is copied to the result file - see writeResult.
+//
+// With opts.NoClobber, an existing result file is
+// reported before any processing.
+//
+// Upon success, the path of the result is returned
+// together with a nil error. Errors from process are
+// returned with an empty string.
+func processInTempDir[T any](d Domain[T], opts FileOptions, spill int64, process func(tempDir string) (*os.File, error)) (string, error) {
+	err := checkOutput(opts)
+	if err != nil {
+		return "", err
+	}
+
+	var res string
+	err = withTempDir(d, opts, spill, func(tempDir string) error {
+		f, err := process(tempDir)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		res, err = writeResult(opts, func(w io.Writer) error {
+			return copyResult(w, f, d, opts)
+		})
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return res, nil
+}
+
+// checkOutput returns an error if the result file exists
+// and must not be replaced according to opts.
+func checkOutput(opts FileOptions) error {
+	if !opts.NoClobber {
+		return nil
+	}
+
+	_, err := os.Lstat(opts.output())
+	if err == nil {
+		return fmt.Errorf("%w: %q", errOutputExists, opts.output())
+	}
+	return nil
+}
+
+// withTempDir checks opts for domain d and runs process
+// in a new temporary directory in opts.TempDir, which is
+// removed afterwards. The filesystem of opts.TempDir must
+// have spill bytes available - see estimateSpill.
+// Errors from process are returned.
+func withTempDir[T any](d Domain[T], opts FileOptions, spill int64, process func(tempDir string) error) error {
+	err := checkMergeOptions(opts.Merge, d)
+	if err != nil {
+		return err
+	}
+
+	// report a budget too small before any processing
+	_, err = opts.limits(intervalSize[T]())
+	if err != nil {
+		return err
+	}
+
+	err = checkFreeSpace(opts.tempDir(), spill)
+	if err != nil {
+		return err
+	}
+
+	tempDir, err := os.MkdirTemp(opts.tempDir(), tempDirPattern)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = os.RemoveAll(tempDir)
+		if err != nil {
+			log.Printf("failed to cleanup temp directory %q\n", tempDir)
+		}
+	}()
+
+	return process(tempDir)
+}
+
+// mergeFile merges the intervals contained in the file at
+// filePath as described in MergeFile, using tempDir for
+// intermediate files - see mergeStream.
+func mergeFile[T any](filePath string, tempDir string, d Domain[T], opts FileOptions) (*os.File, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r, _, err := decompress(file, CompressionOf(filePath))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return mergeStream(r, tempDir, d, opts)
+}
+
+// mergeStream merges the intervals read from r as
+// described in MergeFile, using tempDir for intermediate
+// files.
+//
+// Upon success, the merged intervals are contained in a
+// file in tempDir, which is returned open together with
+// a nil error. It is the responsibility of the caller
+// to close it.
+func mergeStream[T any](r io.Reader, tempDir string, d Domain[T], opts FileOptions) (_ *os.File, err error) {
+	l, err := opts.limits(intervalSize[T]())
+	if err != nil {
+		return nil, err
+	}
+
+	runs, err := splitStream(r, tempDir, l, d, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			closeRuns(runs)
+		}
+	}()
+
+	if len(runs) == 0 {
+		// empty input: produce an empty run
+		f, err := writeTemp(tempDir, d, opts.SpillCompression, func(emit func(Interval[T]) error) error {
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, f)
+	}
+
+	for len(runs) > 1 {
+		n := len(runs)
+		if n > l.fanIn {
+			n = l.fanIn
+		}
+
+		// merged runs are queued last, so that each pass
+		// merges runs of similar size
+		merged, err := mergeRuns(runs[:n], tempDir, d, opts)
+		runs = runs[n:]
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, merged)
+	}
+
+	return runs[0], nil
+}
+
+// writeResult writes the result with write to opts.Output,
+// compressed according to opts.Compression.
+// It is written to a temporary file in the destination
+// directory, synced and then renamed, so that it replaces
+// any previous file atomically - or, with opts.NoClobber,
+// linked, so that it never replaces one.
+//
+// Upon success, the path of the result is returned
+// together with a nil error.
+func writeResult(opts FileOptions, write func(w io.Writer) error) (_ string, err error) {
+	output := opts.output()
+	dir := filepath.Dir(output)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(output)+".*")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	err = tmp.Chmod(0o644)
+	if err != nil {
+		return "", err
+	}
+
+	w := bufio.NewWriter(tmp)
+	err = compressed(w, opts.Compression, write)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	err = tmp.Sync()
+	if err != nil {
+		return "", err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return "", err
+	}
+
+	if opts.NoClobber {
+		err = os.Link(tmp.Name(), output)
+		if errors.Is(err, fs.ErrExist) {
+			err = fmt.Errorf("%w: %q", errOutputExists, output)
+		}
+		if err != nil {
+			return "", err
+		}
+		err = os.Remove(tmp.Name())
+	} else {
+		err = os.Rename(tmp.Name(), output)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return output, syncDir(dir)
+}
+
+// syncDir syncs directory dir, persisting the entries
+// created or renamed in it.
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return f.Sync()
+}
+
+// copyResult writes the interval list in run f to w in the
+// encoding of opts.Format, terminated as by writeEnd.
+func copyResult[T any](w io.Writer, f *os.File, d Domain[T], opts FileOptions) error {
+	count := uint64(unknownCount)
+	if opts.Format.Encoding == Binary && opts.SpillCompression != NoCompression {
+		// the header of compressed runs lacks the number
+		// of intervals, as it cannot be filled in
+		var err error
+		count, err = countRun(f, d, opts.SpillCompression)
+		if err != nil {
+			return err
+		}
+	}
+
+	r, err := openRun(f, opts.SpillCompression)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if opts.Format.Encoding == Binary {
+		// runs are Binary already
+		err = copyRun(w, r, count)
+	} else {
+		// runs are always valid
+		err = transcode[T](newBinaryDecoder(r, d, ParseOptions{}), newEncoder(w, d, opts.Format))
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeEnd(w, opts.Format)
+}
+
+// openRun returns a reader of run f from its start,
+// decompressed according to c. It is the responsibility
+// of the caller to close the reader, and f.
+func openRun(f *os.File, c Compression) (io.ReadCloser, error) {
+	_, err := f.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	r, _, err := decompress(f, c)
+	return r, err
+}
+
+// countRun returns the number of intervals in run f,
+// compressed with c.
+func countRun[T any](f *os.File, d Domain[T], c Compression) (uint64, error) {
+	r, err := openRun(f, c)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	// runs are always valid
+	src := newBinaryDecoder(r, d, ParseOptions{})
+	var n uint64
+	for {
+		_, err := src.next()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		n++
+	}
+}
+
+// copyRun copies the run read from r to w, with count as
+// the number of intervals in its header unless it is
+// unknownCount.
+func copyRun(w io.Writer, r io.Reader, count uint64) error {
+	header := make([]byte, headerSize)
+	_, err := io.ReadFull(r, header)
+	if err != nil {
+		return err
+	}
+	if count != unknownCount {
+		binary.LittleEndian.PutUint64(header[countOffset:], count)
+	}
+
+	_, err = w.Write(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// writeEnd terminates a result written to w in the
+// encoding of opts with a newline, unless it is Binary.
+func writeEnd(w io.Writer, opts FormatOptions) error {
+	if opts.Encoding == Binary {
+		return nil
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// transcode writes the intervals of src with enc.
+func transcode[T any](src source[T], enc encoder[T]) error {
+	for {
+		i, err := src.next()
+		if err == io.EOF {
+			return enc.flush()
+		}
+		if err != nil {
+			return err
+		}
+
+		err = enc.encode(i)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// splitStream splits interval data read from r in multiple
+// files of l.chunkSize bytes. Intervals within each file will
+// already be validated, sorted and merged according to opts:
+// each file is a sorted run.
+//
+// The input is scanned sequentially, while up to
+// l.workers chunks are processed concurrently. The
+// files are returned in the order of the chunks. The first
+// error stops the scan and the remaining workers.
+//
+// It is the responsibility of the caller of this function
+// to cleanup and close the files returned.
+//
+// Input parsing errors or I/O errors will interrupt
+// processing and be returned with no files.
+func splitStream[T any](r io.Reader, tempDir string, l limits, d Domain[T], opts FileOptions) ([]*os.File, error) {
+	// runs[k] is written by the worker processing chunk k,
+	// so that their order does not depend on scheduling
+	var (
+		mu      sync.Mutex
+		runs    []*os.File
+		once    sync.Once
+		workErr error
+		wg      sync.WaitGroup
+	)
+	jobs := make(chan chunk[T])
+	done := make(chan struct{})
+	fail := func(err error) {
+		once.Do(func() {
+			workErr = err
+			close(done)
+		})
+	}
+
+	for w := 0; w < l.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range jobs {
+				select {
+				case <-done:
+					// drain remaining chunks after an error
+					continue
+				default:
+				}
+
+				f, err := writeRun(c, tempDir, d, opts)
+				if err != nil {
+					fail(err)
+					continue
+				}
+
+				mu.Lock()
+				runs[c.k] = f
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// serialize the rejects of concurrent workers
+	if reject := opts.Parse.Reject; reject != nil {
+		var mu sync.Mutex
+		opts.Parse.Reject = func(err *ParseError) {
+			mu.Lock()
+			defer mu.Unlock()
+			reject(err)
+		}
+	}
+
+	var next func() (chunk[T], error)
+	if opts.Parse.Encoding.decoded() {
+		next = decodedChunks(newSource(r, d, opts.Parse), l)
+	} else {
+		next = textChunks[T](r, l, opts.Parse.Encoding)
+	}
+
+scan:
+	for {
+		c, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fail(err)
+			break
+		}
+
+		mu.Lock()
+		c.k = len(runs)
+		runs = append(runs, nil)
+		mu.Unlock()
+
+		select {
+		case jobs <- c:
+		case <-done:
+			break scan
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if workErr != nil {
+		closeRuns(runs)
+		return nil, workErr
+	}
+
+	return runs, nil
+}
+
+// chunk is the k-th chunk of an input file: either its
+// data, found at pos, or its intervals already decoded.
+type chunk[T any] struct {
+	k    int
+	data []byte
+	pos  position
+	list []Interval[T]
+}
+
+// textChunks returns a function returning the chunks of
+// the input read from r in encoding e - Text, Lines or
+// NDJSON - one at a time, or io.EOF after the last one.
+// Each chunk is cut after the last closing bracket within
+// l.chunkSize bytes, or after the last newline for line
+// oriented encodings.
+func textChunks[T any](r io.Reader, l limits, e Encoding) func() (chunk[T], error) {
+	scanner := bufio.NewScanner(r)
+
+	buf := make([]byte, l.chunkSize)
+	// ensure enough buffer space for scenarios including whitespace characters
+	scanner.Buffer(buf, minBufferSize)
+
+	// end of the last interval in data, and the number
+	// of intervals in a chunk
+	lastEnd := func(data []byte) int {
+		return bytes.LastIndexAny(data, "])")
+	}
+	count := func(data []byte) int {
+		return bytes.Count(data, []byte("]")) + bytes.Count(data, []byte(")"))
+	}
+	if e.lineOriented() {
+		lastEnd = func(data []byte) int {
+			return bytes.LastIndexByte(data, '\n')
+		}
+	}
+	if e == NDJSON {
+		count = countLines
+	}
+
+	// scan input such that the *maximum possible amount of intervals* fit in the buffer
+	scanner.Split(func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		// find the *last* end of an interval
+		closingIdx := lastEnd(data)
+		if closingIdx >= 0 {
+			// return remaining data past the closing bracket
+			buffer := data[:closingIdx+1]
+
+			// advance to the first rune past the closing bracket
+			return closingIdx + 1, buffer, nil
+		}
+
+		// return remaining data if it's the end of the file
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+
+		// continue reading
+		return 0, nil, nil
+	})
+
+	// position of the next chunk in the input
+	pos := startPosition()
+
+	return func() (chunk[T], error) {
+		if !scanner.Scan() {
+			err := scanner.Err()
+			if errors.Is(err, bufio.ErrTooLong) {
+				err = fmt.Errorf("interval after offset %d does not fit the chunk size of %d bytes: %w", pos.offset, l.chunkSize, errBadInput)
+			}
+			if err == nil {
+				err = io.EOF
+			}
+			return chunk[T]{}, err
+		}
+
+		// the scanner reuses its buffer for the next chunk
+		c := chunk[T]{data: bytes.Clone(scanner.Bytes()), pos: pos}
+		pos.advance(c.data)
+		pos.n += count(c.data)
+
+		return c, nil
+	}
+}
+
+// decodedChunks returns a function returning the chunks of
+// the intervals read from src one at a time, or io.EOF
+// after the last one. Each chunk holds as many intervals
+// as a Text encoded chunk of l.chunkSize bytes may hold.
+//
+// Unlike Text encoded chunks, they are decoded
+// sequentially, as the encoding cannot be split into
+// chunks without decoding it.
+func decodedChunks[T any](src source[T], l limits) func() (chunk[T], error) {
+	size := l.chunkSize / minIntervalText
+
+	return func() (chunk[T], error) {
+		var c chunk[T]
+		for len(c.list) < size {
+			i, err := src.next()
+			if err == io.EOF && len(c.list) > 0 {
+				break
+			}
+			if err != nil {
+				return chunk[T]{}, err
+			}
+
+			c.list = append(c.list, i)
+		}
+
+		return c, nil
+	}
+}
+
+// writeRun sorts and merges the intervals in chunk c,
+// parsing them first if needed, according to opts and
+// writes them to a new file in tempDir: a sorted run.
+//
+// Upon success, the run is returned open together with
+// a nil error.
+func writeRun[T any](c chunk[T], tempDir string, d Domain[T], opts FileOptions) (*os.File, error) {
+	intervals := c.list
+	if c.data != nil {
+		var err error
+		intervals, err = parseAll(newSourceAt(bytes.NewReader(c.data), d, opts.Parse, c.pos))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	intervals, err := MergeWith(intervals, d, opts.Merge)
+	if err != nil {
+		return nil, err
+	}
+
+	return writeTemp(tempDir, d, opts.SpillCompression, func(emit func(Interval[T]) error) error {
+		for _, i := range intervals {
+			err := emit(i)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// mergeRuns merges the sorted runs into a new run in
+// tempDir, connecting intervals according to opts.Merge,
+// and removes them. Only one interval per run is held in
+// memory at a time.
+//
+// Upon success, the new run is returned open together
+// with a nil error.
+func mergeRuns[T any](runs []*os.File, tempDir string, d Domain[T], opts FileOptions) (*os.File, error) {
+	defer closeRuns(runs)
+
+	srcs := make([]source[T], len(runs))
+	for k, f := range runs {
+		r, err := openRun(f, opts.SpillCompression)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+
+		// runs are always valid
+		srcs[k] = newBinaryDecoder(r, d, ParseOptions{})
+	}
+
+	return writeTemp(tempDir, d, opts.SpillCompression, func(emit func(Interval[T]) error) error {
+		return kWayMerge(srcs, d, opts.Merge, emit)
+	})
+}
+
+// closeRuns closes and removes the run files.
+// Nil entries are skipped.
+func closeRuns(runs []*os.File) {
+	for _, f := range runs {
+		if f == nil {
+			continue
+		}
+
+		f.Close()
+		err := os.Remove(f.Name())
+		if err != nil {
+			log.Printf("failed to remove temp file %q\n", f.Name())
+		}
+	}
+}
+
+// kWayMerge reads the sorted intervals of all srcs and calls
+// emit with them in ascending order, connected according to
+// opts. A heap holds the next interval of each source.
+//
+// Any ocurring errors from srcs or emit interrupt
+// processing and are returned.
+func kWayMerge[T any](srcs []source[T], d Domain[T], opts MergeOptions, emit func(Interval[T]) error) error {
+	h := &runHeap[T]{cmp: d.Compare}
+	for _, src := range srcs {
+		i, err := src.next()
+		if err == io.EOF {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		h.items = append(h.items, runItem[T]{interval: i, src: src})
+	}
+	heap.Init(h)
+
+	out := coalescer[T]{d: d, opts: opts, emit: emit}
+	for h.Len() > 0 {
+		top := &h.items[0]
+		err := out.add(top.interval)
+		if err != nil {
+			return err
+		}
+
+		top.interval, err = top.src.next()
+		if err == io.EOF {
+			heap.Pop(h)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		heap.Fix(h, 0)
+	}
+
+	return out.flush()
+}
+
+// runItem is the next interval of a sorted run.
+type runItem[T any] struct {
+	interval Interval[T]
+	src      source[T]
+}
+
+// runHeap orders the next intervals of several sorted
+// runs by left endpoint. It implements heap.Interface.
+type runHeap[T any] struct {
+	items []runItem[T]
+	cmp   func(a, b T) int
+}
+
+func (h *runHeap[T]) Len() int { return len(h.items) }
+
+func (h *runHeap[T]) Less(a, b int) bool {
+	return compareLeft(h.items[a].interval, h.items[b].interval, h.cmp) < 0
+}
+
+func (h *runHeap[T]) Swap(a, b int) { h.items[a], h.items[b] = h.items[b], h.items[a] }
+
+func (h *runHeap[T]) Push(x any) { h.items = append(h.items, x.(runItem[T])) }
+
+func (h *runHeap[T]) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
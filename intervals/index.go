@@ -0,0 +1,87 @@
+package intervals
+
+import (
+	"sort"
+)
+
+// Index answers point and range queries on a merged list
+// of intervals with binary search, in O(log n) per query
+// plus the number of intervals returned.
+//
+// An Index is immutable and safe for concurrent use.
+// Use Tree to query lists of overlapping intervals.
+type Index[T any] struct {
+	list []Interval[T]
+	d    Domain[T]
+}
+
+// NewIndex returns an Index of the merged list, e.g. as
+// returned by Merge. The list is copied: later changes
+// to it do not affect the Index.
+func NewIndex[T any](merged []Interval[T], d Domain[T]) *Index[T] {
+	list := make([]Interval[T], len(merged))
+	copy(list, merged)
+
+	return &Index[T]{list: list, d: d}
+}
+
+// Len returns the number of intervals in the Index.
+func (idx *Index[T]) Len() int {
+	return len(idx.list)
+}
+
+// Stab returns the interval containing point p and true,
+// or an empty interval and false if no interval does.
+func (idx *Index[T]) Stab(p T) (Interval[T], bool) {
+	point := Interval[T]{X: p, Y: p}
+
+	// first interval not ending before p
+	k := sort.Search(len(idx.list), func(k int) bool {
+		return !idx.list[k].before(point, idx.d)
+	})
+	if k < len(idx.list) && idx.list[k].contains(p, idx.d) {
+		return idx.list[k], true
+	}
+
+	return Interval[T]{}, false
+}
+
+// Overlapping returns the intervals having at least one
+// value in common with q, in ascending order.
+func (idx *Index[T]) Overlapping(q Interval[T]) []Interval[T] {
+	// first interval not ending before q begins
+	k := sort.Search(len(idx.list), func(k int) bool {
+		return !idx.list[k].before(q, idx.d)
+	})
+
+	res := make([]Interval[T], 0)
+	for ; k < len(idx.list) && !q.before(idx.list[k], idx.d); k++ {
+		if idx.list[k].overlaps(q, idx.d) {
+			res = append(res, idx.list[k])
+		}
+	}
+
+	return res
+}
+
+// contains reports whether p is a value of interval i.
+func (i Interval[T]) contains(p T, d Domain[T]) bool {
+	return i.overlaps(Interval[T]{X: p, Y: p}, d)
+}
+
+// overlaps reports whether intervals a and b have at least
+// one value of domain d in common.
+func (a Interval[T]) overlaps(b Interval[T], d Domain[T]) bool {
+	// the common part begins with the later start and
+	// ends with the earlier end
+	start, end := a, b
+	if compareLeft(a, b, d.Compare) < 0 {
+		start = b
+	}
+	if compareRight(a, b, d.Compare) < 0 {
+		end = a
+	}
+
+	common := Interval[T]{X: start.X, Y: end.Y, Bounds: start.Bounds&LeftOpen | end.Bounds&RightOpen}
+	return d.Compare(common.X, common.Y) <= 0 && !common.isEmpty(d)
+}
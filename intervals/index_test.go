@@ -0,0 +1,85 @@
+package intervals
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndexStab(t *testing.T) {
+	list, err := Parse(strings.NewReader("[1,3] (5,7) [9,9] [10,12)"), Float64)
+	assert.NoError(t, err)
+	idx := NewIndex(list, Float64)
+
+	testcases := []struct {
+		point    float64
+		expected string
+	}{
+		{point: 0, expected: ""},
+		{point: 1, expected: "[1,3]"},
+		{point: 3, expected: "[1,3]"},
+		{point: 4, expected: ""},
+		{point: 5, expected: ""},
+		{point: 6, expected: "(5,7)"},
+		{point: 7, expected: ""},
+		{point: 9, expected: "[9,9]"},
+		{point: 11.5, expected: "[10,12)"},
+		{point: 12, expected: ""},
+		{point: 13, expected: ""},
+	}
+
+	for _, test := range testcases {
+		i, ok := idx.Stab(test.point)
+		if test.expected == "" {
+			assert.False(t, ok, fmt.Sprintf("testcase: %+v", test))
+			continue
+		}
+		assert.True(t, ok, fmt.Sprintf("testcase: %+v", test))
+		assert.Equal(t, test.expected, Format([]Interval[float64]{i}, Float64), fmt.Sprintf("testcase: %+v", test))
+	}
+}
+
+func TestIndexOverlapping(t *testing.T) {
+	list, err := Parse(strings.NewReader("[1,3] (5,7) [9,9] [10,12)"), Float64)
+	assert.NoError(t, err)
+	idx := NewIndex(list, Float64)
+	assert.Equal(t, 4, idx.Len())
+
+	testcases := []struct {
+		query    Interval[float64]
+		expected string
+	}{
+		{query: Interval[float64]{X: -1, Y: 0}, expected: ""},
+		{query: Interval[float64]{X: 0, Y: 20}, expected: "[1,3] (5,7) [9,9] [10,12)"},
+		{query: Interval[float64]{X: 3, Y: 5}, expected: "[1,3]"},
+		{query: Interval[float64]{X: 3, Y: 5, Bounds: LeftOpen}, expected: ""},
+		{query: Interval[float64]{X: 6, Y: 9}, expected: "(5,7) [9,9]"},
+		{query: Interval[float64]{X: 9, Y: 10, Bounds: Open}, expected: ""},
+		{query: Interval[float64]{X: 12, Y: 13}, expected: ""},
+	}
+
+	for _, test := range testcases {
+		assert.Equal(t, test.expected, Format(idx.Overlapping(test.query), Float64), fmt.Sprintf("testcase: %+v", test))
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	testcases := []struct {
+		a, b     Interval[int]
+		expected bool
+	}{
+		{a: Interval[int]{X: 1, Y: 3}, b: Interval[int]{X: 3, Y: 5}, expected: true},
+		{a: Interval[int]{X: 1, Y: 3, Bounds: RightOpen}, b: Interval[int]{X: 3, Y: 5}, expected: false},
+		{a: Interval[int]{X: 1, Y: 5}, b: Interval[int]{X: 2, Y: 3}, expected: true},
+		{a: Interval[int]{X: 1, Y: 2}, b: Interval[int]{X: 3, Y: 4}, expected: false},
+		// no integer in common
+		{a: Interval[int]{X: 3, Y: 5, Bounds: Open}, b: Interval[int]{X: 4, Y: 6, Bounds: Open}, expected: false},
+	}
+
+	for _, test := range testcases {
+		assert.Equal(t, test.expected, test.a.overlaps(test.b, Int), fmt.Sprintf("testcase: %+v", test))
+		assert.Equal(t, test.expected, test.b.overlaps(test.a, Int), fmt.Sprintf("testcase: %+v", test))
+	}
+}
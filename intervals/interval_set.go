@@ -0,0 +1,226 @@
+package intervals
+
+import (
+	"math/rand"
+)
+
+// IntervalSet is a mutable set of values of a domain, kept
+// as a merged list of intervals. Intervals can be inserted
+// and removed one at a time, e.g. from an event stream,
+// without re-sorting the whole list as Merge does.
+//
+// It is backed by a treap, a randomized balanced binary
+// search tree: Insert and Remove take O(log n) expected
+// time plus the number of intervals they merge or cut,
+// Contains takes O(log n) expected time.
+//
+// An IntervalSet is not safe for concurrent use.
+type IntervalSet[T any] struct {
+	root *setNode[T]
+	len  int
+	d    Domain[T]
+}
+
+// setNode is a node of the treap: ordered by interval
+// as a binary search tree, and by prio as a heap.
+type setNode[T any] struct {
+	interval    Interval[T]
+	prio        uint32
+	left, right *setNode[T]
+}
+
+// NewIntervalSet returns an empty IntervalSet of values
+// of domain d.
+func NewIntervalSet[T any](d Domain[T]) *IntervalSet[T] {
+	return &IntervalSet[T]{d: d}
+}
+
+// Len returns the number of intervals in the set.
+func (s *IntervalSet[T]) Len() int {
+	return s.len
+}
+
+// Insert adds the values of interval i to the set. Intervals
+// of the set overlapping i or sharing an included endpoint
+// with it are merged with i, as Merge does.
+// Empty or reversed intervals are ignored.
+func (s *IntervalSet[T]) Insert(i Interval[T]) {
+	if s.d.Compare(i.X, i.Y) > 0 || i.isEmpty(s.d) {
+		return
+	}
+
+	// intervals before i and not connected to it
+	left, rest := splitSet(s.root, func(e Interval[T]) bool {
+		c := s.d.Compare(e.Y, i.X)
+		return c < 0 || c == 0 && e.rightOpen() && i.leftOpen()
+	})
+	// intervals connected to i
+	connected, right := splitSet(rest, func(e Interval[T]) bool {
+		c := s.d.Compare(i.Y, e.X)
+		return !(c < 0 || c == 0 && i.rightOpen() && e.leftOpen())
+	})
+
+	if connected != nil {
+		first, last := connected.first(), connected.last()
+		if compareLeft(first, i, s.d.Compare) < 0 {
+			i = Interval[T]{X: first.X, Y: i.Y, Bounds: first.Bounds&LeftOpen | i.Bounds&RightOpen}
+		}
+		if compareRight(last, i, s.d.Compare) > 0 {
+			i = Interval[T]{X: i.X, Y: last.Y, Bounds: i.Bounds&LeftOpen | last.Bounds&RightOpen}
+		}
+		s.len -= connected.count()
+	}
+
+	s.len++
+	s.root = joinSet(joinSet(left, newSetNode(i)), right)
+}
+
+// Remove removes the values of interval i from the set.
+// Intervals of the set overlapping i are cut back, or
+// split in two if i lies within them, e.g. removing [3,4]
+// from [1,6] leaves [1,3) and (4,6].
+func (s *IntervalSet[T]) Remove(i Interval[T]) {
+	if s.d.Compare(i.X, i.Y) > 0 || i.isEmpty(s.d) {
+		return
+	}
+
+	left, rest := splitSet(s.root, func(e Interval[T]) bool {
+		return e.before(i, s.d)
+	})
+	overlapping, right := splitSet(rest, func(e Interval[T]) bool {
+		return !i.before(e, s.d)
+	})
+
+	if overlapping != nil {
+		first, last := overlapping.first(), overlapping.last()
+		s.len -= overlapping.count()
+
+		if compareLeft(first, i, s.d.Compare) < 0 {
+			if head := first.head(i); !head.isEmpty(s.d) {
+				left = joinSet(left, newSetNode(head))
+				s.len++
+			}
+		}
+		if compareRight(last, i, s.d.Compare) > 0 {
+			if tail := last.tail(i); !tail.isEmpty(s.d) {
+				right = joinSet(newSetNode(tail), right)
+				s.len++
+			}
+		}
+	}
+
+	s.root = joinSet(left, right)
+}
+
+// Contains reports whether p is a value of the set.
+func (s *IntervalSet[T]) Contains(p T) bool {
+	n := s.root
+	for n != nil {
+		if n.interval.contains(p, s.d) {
+			return true
+		}
+
+		if c := s.d.Compare(p, n.interval.X); c < 0 || c == 0 && n.interval.leftOpen() {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+
+	return false
+}
+
+// Each calls fn for each interval of the set in ascending
+// order, until fn returns false.
+// The set must not be modified while iterating.
+func (s *IntervalSet[T]) Each(fn func(Interval[T]) bool) {
+	s.root.each(fn)
+}
+
+// Intervals returns the merged intervals of the set
+// in ascending order.
+func (s *IntervalSet[T]) Intervals() []Interval[T] {
+	res := make([]Interval[T], 0, s.len)
+	s.Each(func(i Interval[T]) bool {
+		res = append(res, i)
+		return true
+	})
+
+	return res
+}
+
+func newSetNode[T any](i Interval[T]) *setNode[T] {
+	return &setNode[T]{interval: i, prio: rand.Uint32()}
+}
+
+// each calls fn for the intervals of the subtree in order.
+// It returns false if fn stopped the iteration.
+func (n *setNode[T]) each(fn func(Interval[T]) bool) bool {
+	if n == nil {
+		return true
+	}
+
+	return n.left.each(fn) && fn(n.interval) && n.right.each(fn)
+}
+
+// first returns the leftmost interval of the subtree.
+func (n *setNode[T]) first() Interval[T] {
+	for n.left != nil {
+		n = n.left
+	}
+	return n.interval
+}
+
+// last returns the rightmost interval of the subtree.
+func (n *setNode[T]) last() Interval[T] {
+	for n.right != nil {
+		n = n.right
+	}
+	return n.interval
+}
+
+// count returns the number of nodes in the subtree.
+func (n *setNode[T]) count() int {
+	if n == nil {
+		return 0
+	}
+	return 1 + n.left.count() + n.right.count()
+}
+
+// splitSet splits the treap n into the nodes whose interval
+// satisfies inLeft and the remaining ones. inLeft must hold
+// for a prefix of the intervals in order.
+func splitSet[T any](n *setNode[T], inLeft func(Interval[T]) bool) (*setNode[T], *setNode[T]) {
+	if n == nil {
+		return nil, nil
+	}
+
+	if inLeft(n.interval) {
+		var right *setNode[T]
+		n.right, right = splitSet(n.right, inLeft)
+		return n, right
+	}
+
+	var left *setNode[T]
+	left, n.left = splitSet(n.left, inLeft)
+	return left, n
+}
+
+// joinSet joins the treaps l and r, where all intervals
+// of l come before all intervals of r.
+func joinSet[T any](l, r *setNode[T]) *setNode[T] {
+	if l == nil {
+		return r
+	}
+	if r == nil {
+		return l
+	}
+
+	if l.prio > r.prio {
+		l.right = joinSet(l.right, r)
+		return l
+	}
+
+	r.left = joinSet(l, r.left)
+	return r
+}
@@ -0,0 +1,113 @@
+package intervals
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntervalSet(t *testing.T) {
+	testcases := []struct {
+		insert   string
+		remove   string
+		expected string
+	}{
+		{insert: "", remove: "", expected: ""},
+		{insert: "[1,3] [5,7]", remove: "", expected: "[1,3] [5,7]"},
+		{insert: "[5,7] [1,3] [2,6]", remove: "", expected: "[1,7]"},
+		{insert: "[1,3] [3,5]", remove: "", expected: "[1,5]"},
+		{insert: "[1,3) (3,5]", remove: "", expected: "[1,3) (3,5]"},
+		{insert: "[1,3) [3,5] (0,1]", remove: "", expected: "(0,5]"},
+		{insert: "[1,2] [4,5] [7,8] [3,7]", remove: "", expected: "[1,2] [3,8]"},
+		{insert: "[1,10]", remove: "[3,4]", expected: "[1,3) (4,10]"},
+		{insert: "[1,10]", remove: "(3,4)", expected: "[1,3] [4,10]"},
+		{insert: "[1,10]", remove: "[1,4]", expected: "(4,10]"},
+		{insert: "[1,10]", remove: "[0,20]", expected: ""},
+		{insert: "[1,3] [5,7] [9,11]", remove: "[2,10]", expected: "[1,2) (10,11]"},
+		{insert: "[1,3] [5,7]", remove: "[3,5]", expected: "[1,3) (5,7]"},
+		{insert: "[1,3) [5,7]", remove: "[3,4]", expected: "[1,3) [5,7]"},
+		{insert: "[1,3] [5,7]", remove: "[8,9]", expected: "[1,3] [5,7]"},
+	}
+
+	for _, test := range testcases {
+		set := NewIntervalSet(Float64)
+
+		insert, err := Parse(strings.NewReader(test.insert), Float64)
+		assert.NoError(t, err)
+		for _, i := range insert {
+			set.Insert(i)
+		}
+
+		remove, err := Parse(strings.NewReader(test.remove), Float64)
+		assert.NoError(t, err)
+		for _, i := range remove {
+			set.Remove(i)
+		}
+
+		assert.Equal(t, test.expected, Format(set.Intervals(), Float64), fmt.Sprintf("testcase: %+v", test))
+		assert.Equal(t, len(set.Intervals()), set.Len(), fmt.Sprintf("testcase: %+v", test))
+	}
+}
+
+func TestIntervalSetDiscrete(t *testing.T) {
+	set := NewIntervalSet(Int)
+	set.Insert(Interval[int]{X: 1, Y: 10})
+	set.Remove(Interval[int]{X: 3, Y: 4, Bounds: Open})
+	set.Remove(Interval[int]{X: 10, Y: 10})
+	set.Insert(Interval[int]{X: 20, Y: 10})
+
+	assert.Equal(t, "[1,10)", Format(set.Intervals(), Int))
+	assert.True(t, set.Contains(9))
+	assert.False(t, set.Contains(10))
+}
+
+func TestIntervalSetEach(t *testing.T) {
+	set := NewIntervalSet(Int)
+	for _, x := range []int{9, 1, 5, 3, 7} {
+		set.Insert(Interval[int]{X: x, Y: x})
+	}
+
+	var visited []int
+	set.Each(func(i Interval[int]) bool {
+		visited = append(visited, i.X)
+		return i.X < 5
+	})
+
+	assert.Equal(t, []int{1, 3, 5}, visited)
+}
+
+func TestIntervalSetMatchesBitmap(t *testing.T) {
+	const size = 200
+
+	r := rand.New(rand.NewSource(1))
+	set := NewIntervalSet(Int)
+	var bitmap [size]bool
+
+	for n := 0; n < 2000; n++ {
+		x := r.Intn(size - 20)
+		i := Interval[int]{X: x, Y: x + r.Intn(20), Bounds: Bounds(r.Intn(4))}
+
+		insert := r.Intn(3) > 0
+		if insert {
+			set.Insert(i)
+		} else {
+			set.Remove(i)
+		}
+		for p := 0; p < size; p++ {
+			if i.contains(p, Int) {
+				bitmap[p] = insert
+			}
+		}
+
+		for p := 0; p < size; p++ {
+			assert.Equal(t, bitmap[p], set.Contains(p), fmt.Sprintf("step %d: point %d", n, p))
+		}
+
+		list := set.Intervals()
+		assert.Equal(t, len(list), set.Len())
+		assert.Equal(t, len(Merge(list, Int)), len(list), fmt.Sprintf("step %d: not merged %s", n, Format(list, Int)))
+	}
+}
@@ -0,0 +1,900 @@
+package intervals
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/slices"
+)
+
+func TestMerge(t *testing.T) {
+	testcases := []struct {
+		intervals []Interval[int]
+		expected  []Interval[int]
+	}{
+		// Empty input
+		{
+			intervals: []Interval[int]{},
+			expected:  []Interval[int]{},
+		},
+		// Single interval input
+		{
+			intervals: []Interval[int]{
+				{X: 1, Y: 2},
+			},
+			expected: []Interval[int]{
+				{X: 1, Y: 2},
+			},
+		},
+		// Repeated Interval[int]
+		{
+			intervals: []Interval[int]{
+				{X: 1, Y: 2},
+				{X: 1, Y: 2},
+			},
+			expected: []Interval[int]{
+				{X: 1, Y: 2},
+			},
+		},
+		// Two intervals that merge
+		{
+			intervals: []Interval[int]{
+				{X: 1, Y: 2},
+				{X: 2, Y: 3},
+			},
+			expected: []Interval[int]{
+				{X: 1, Y: 3},
+			},
+		},
+		// Two intervals that do not merge
+		{
+			intervals: []Interval[int]{
+				{X: 1, Y: 2},
+				{X: 3, Y: 4},
+			},
+			expected: []Interval[int]{
+				{X: 1, Y: 2},
+				{X: 3, Y: 4},
+			},
+		},
+		// Three intervals: first and second merge
+		{
+			intervals: []Interval[int]{
+				{X: 1, Y: 2},
+				{X: 2, Y: 3},
+				{X: 4, Y: 5},
+			},
+			expected: []Interval[int]{
+				{X: 1, Y: 3},
+				{X: 4, Y: 5},
+			},
+		},
+		// Three intervals: second and third merge
+		{
+			intervals: []Interval[int]{
+				{X: 1, Y: 2},
+				{X: 3, Y: 4},
+				{X: 4, Y: 5},
+			},
+			expected: []Interval[int]{
+				{X: 1, Y: 2},
+				{X: 3, Y: 5},
+			},
+		},
+		// Three intervals: no merge
+		{
+			intervals: []Interval[int]{
+				{X: 1, Y: 2},
+				{X: 3, Y: 4},
+				{X: 5, Y: 6},
+			},
+			expected: []Interval[int]{
+				{X: 1, Y: 2},
+				{X: 3, Y: 4},
+				{X: 5, Y: 6},
+			},
+		},
+		// Alternate merge | no merge
+		{
+			intervals: []Interval[int]{
+				{X: 1, Y: 2},
+				{X: 2, Y: 3},
+				{X: 4, Y: 5},
+				{X: 6, Y: 7},
+				{X: 7, Y: 8},
+				{X: 9, Y: 10},
+			},
+			expected: []Interval[int]{
+				{X: 1, Y: 3},
+				{X: 4, Y: 5},
+				{X: 6, Y: 8},
+				{X: 9, Y: 10},
+			},
+		},
+		// Dot - no merge
+		{
+			intervals: []Interval[int]{
+				{X: 1, Y: 2},
+				{X: 3, Y: 3},
+				{X: 4, Y: 5},
+			},
+			expected: []Interval[int]{
+				{X: 1, Y: 2},
+				{X: 3, Y: 3},
+				{X: 4, Y: 5},
+			},
+		},
+		// Dot - merge
+		{
+			intervals: []Interval[int]{
+				{X: 1, Y: 3},
+				{X: 3, Y: 3},
+				{X: 3, Y: 5},
+			},
+			expected: []Interval[int]{
+				{X: 1, Y: 5},
+			},
+		},
+		// Half-open intervals sharing an endpoint - merge
+		{
+			intervals: []Interval[int]{
+				{X: 2, Y: 3, Bounds: Closed},
+				{X: 1, Y: 2, Bounds: RightOpen},
+			},
+			expected: []Interval[int]{
+				{X: 1, Y: 3, Bounds: Closed},
+			},
+		},
+		// Shared endpoint excluded from both - no merge
+		{
+			intervals: []Interval[int]{
+				{X: 2, Y: 3, Bounds: LeftOpen},
+				{X: 1, Y: 2, Bounds: RightOpen},
+			},
+			expected: []Interval[int]{
+				{X: 1, Y: 2, Bounds: RightOpen},
+				{X: 2, Y: 3, Bounds: LeftOpen},
+			},
+		},
+		// Equal endpoints - included endpoint wins
+		{
+			intervals: []Interval[int]{
+				{X: 1, Y: 3, Bounds: Open},
+				{X: 1, Y: 3, Bounds: Closed},
+			},
+			expected: []Interval[int]{
+				{X: 1, Y: 3, Bounds: Closed},
+			},
+		},
+		// Testcase from assignment
+		{
+			intervals: []Interval[int]{
+				{X: 25, Y: 30},
+				{X: 2, Y: 19},
+				{X: 14, Y: 23},
+				{X: 4, Y: 8},
+			},
+			expected: []Interval[int]{
+				{X: 2, Y: 23},
+				{X: 25, Y: 30},
+			},
+		},
+	}
+
+	for _, test := range testcases {
+		assert.Equal(t, test.expected, Merge(test.intervals, Int), fmt.Sprintf("testcase %+v", test))
+	}
+}
+
+func TestMergeWith(t *testing.T) {
+	testcases := []struct {
+		intervals []Interval[int]
+		opts      MergeOptions
+		expected  []Interval[int]
+	}{
+		// Adjacent integers merge
+		{
+			intervals: []Interval[int]{
+				{X: 3, Y: 4},
+				{X: 1, Y: 2},
+				{X: 6, Y: 7},
+			},
+			opts: MergeOptions{Adjacent: true},
+			expected: []Interval[int]{
+				{X: 1, Y: 4},
+				{X: 6, Y: 7},
+			},
+		},
+		// Open endpoints are not adjacent to the next integer
+		{
+			intervals: []Interval[int]{
+				{X: 1, Y: 3, Bounds: RightOpen},
+				{X: 3, Y: 4, Bounds: LeftOpen},
+				{X: 5, Y: 6},
+			},
+			opts: MergeOptions{Adjacent: true},
+			expected: []Interval[int]{
+				{X: 1, Y: 3, Bounds: RightOpen},
+				{X: 3, Y: 6, Bounds: LeftOpen},
+			},
+		},
+		// Gap tolerance
+		{
+			intervals: []Interval[int]{
+				{X: 1, Y: 2},
+				{X: 4, Y: 5},
+				{X: 8, Y: 9},
+			},
+			opts: MergeOptions{Gap: 2},
+			expected: []Interval[int]{
+				{X: 1, Y: 5},
+				{X: 8, Y: 9},
+			},
+		},
+		// Gap tolerance on top of adjacency
+		{
+			intervals: []Interval[int]{
+				{X: 1, Y: 2},
+				{X: 5, Y: 6},
+				{X: 10, Y: 11},
+			},
+			opts: MergeOptions{Adjacent: true, Gap: 2},
+			expected: []Interval[int]{
+				{X: 1, Y: 6},
+				{X: 10, Y: 11},
+			},
+		},
+		// No overflow at the limits of the domain
+		{
+			intervals: []Interval[int]{
+				{X: math.MaxInt - 1, Y: math.MaxInt},
+				{X: math.MinInt, Y: math.MinInt + 1},
+			},
+			opts: MergeOptions{Adjacent: true, Gap: 10},
+			expected: []Interval[int]{
+				{X: math.MinInt, Y: math.MinInt + 1},
+				{X: math.MaxInt - 1, Y: math.MaxInt},
+			},
+		},
+	}
+
+	for _, test := range testcases {
+		res, err := MergeWith(test.intervals, Int, test.opts)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, res, fmt.Sprintf("testcase %+v", test))
+	}
+
+	_, err := MergeWith([]Interval[float64]{}, Float64, MergeOptions{Adjacent: true})
+	assert.ErrorIs(t, err, errNotDiscrete)
+
+	_, err = MergeWith([]Interval[int]{}, Int, MergeOptions{Gap: -1})
+	assert.ErrorIs(t, err, errNegativeGap)
+
+	_, err = MergeWith([]Interval[int]{}, Domain[int]{Compare: Int.Compare}, MergeOptions{Gap: 1})
+	assert.ErrorIs(t, err, errNoAdd)
+}
+
+func TestMergeIfSortedAndOverlap(t *testing.T) {
+	testcases := []struct {
+		a       Interval[int]
+		b       Interval[int]
+		overlap bool
+		merged  Interval[int]
+	}{
+		// A [x,   y]
+		// B [x,y]
+		{
+			a:       Interval[int]{X: 1, Y: 3},
+			b:       Interval[int]{X: 1, Y: 2},
+			overlap: true,
+			merged:  Interval[int]{X: 1, Y: 3},
+		},
+		// A [x,y]
+		// B [x,y]
+		{
+			a:       Interval[int]{X: 1, Y: 2},
+			b:       Interval[int]{X: 1, Y: 2},
+			overlap: true,
+			merged:  Interval[int]{X: 1, Y: 2},
+		},
+		// A [x,y]
+		// B [x,   y]
+		{
+			a:       Interval[int]{X: 1, Y: 2},
+			b:       Interval[int]{X: 1, Y: 3},
+			overlap: true,
+			merged:  Interval[int]{X: 1, Y: 3},
+		},
+		// A [x,   y]
+		// B   [x,   y]
+		{
+			a:       Interval[int]{X: 1, Y: 3},
+			b:       Interval[int]{X: 2, Y: 4},
+			overlap: true,
+			merged:  Interval[int]{X: 1, Y: 4},
+		},
+		// A [x,   y]
+		// B    [x,y]
+		{
+			a:       Interval[int]{X: 1, Y: 3},
+			b:       Interval[int]{X: 2, Y: 3},
+			overlap: true,
+			merged:  Interval[int]{X: 1, Y: 3},
+		},
+		// A [x,     y]
+		// B   [x,y]
+		{
+			a:       Interval[int]{X: 1, Y: 4},
+			b:       Interval[int]{X: 2, Y: 3},
+			overlap: true,
+			merged:  Interval[int]{X: 1, Y: 4},
+		},
+		// A: [x,y]
+		// B:       [x,y]
+		{
+			a:       Interval[int]{X: 1, Y: 2},
+			b:       Interval[int]{X: 3, Y: 4},
+			overlap: false,
+			merged:  Interval[int]{},
+		},
+		// A:   [x,y]
+		// B: [x,y]
+		{
+			a:       Interval[int]{X: 2, Y: 3},
+			b:       Interval[int]{X: 1, Y: 2},
+			overlap: false,
+			merged:  Interval[int]{},
+		},
+		// A:       [x,y]
+		// B: [x,y]
+		{
+			a:       Interval[int]{X: 3, Y: 4},
+			b:       Interval[int]{X: 1, Y: 2},
+			overlap: false,
+			merged:  Interval[int]{},
+		},
+		// A: [x,y)
+		// B:     [x,y]
+		{
+			a:       Interval[int]{X: 1, Y: 2, Bounds: RightOpen},
+			b:       Interval[int]{X: 2, Y: 3},
+			overlap: true,
+			merged:  Interval[int]{X: 1, Y: 3},
+		},
+		// A: [x,y)
+		// B:     (x,y]
+		{
+			a:       Interval[int]{X: 1, Y: 2, Bounds: RightOpen},
+			b:       Interval[int]{X: 2, Y: 3, Bounds: LeftOpen},
+			overlap: false,
+			merged:  Interval[int]{},
+		},
+		// A: [x,   y]
+		// B:   (x,y)
+		{
+			a:       Interval[int]{X: 1, Y: 3},
+			b:       Interval[int]{X: 2, Y: 3, Bounds: Open},
+			overlap: true,
+			merged:  Interval[int]{X: 1, Y: 3},
+		},
+		// A: (x,y)
+		// B: [x,   y)
+		{
+			a:       Interval[int]{X: 1, Y: 2, Bounds: Open},
+			b:       Interval[int]{X: 1, Y: 3, Bounds: RightOpen},
+			overlap: false,
+			merged:  Interval[int]{},
+		},
+	}
+
+	for _, test := range testcases {
+		merged, ok := test.a.mergeIfSortedAndOverlap(test.b, Int, MergeOptions{})
+		assert.Equal(t, test.overlap, ok, fmt.Sprintf("testcase: %+v", test))
+		assert.Equal(t, test.merged, merged, fmt.Sprintf("testcase: %+v", test))
+	}
+}
+
+func TestParse(t *testing.T) {
+	testcases := []struct {
+		input    string
+		expected []Interval[int]
+		error    error
+	}{
+		{
+			input: "[1,2] [3, 4] [ 5,6] [   7   ,   8   ][9,10][11,12]",
+			expected: []Interval[int]{
+				{X: 1, Y: 2},
+				{X: 3, Y: 4},
+				{X: 5, Y: 6},
+				{X: 7, Y: 8},
+				{X: 9, Y: 10},
+				{X: 11, Y: 12},
+			},
+		},
+		{
+			input: "[1,2) (3, 4] ( 5,6 )[7,8]",
+			expected: []Interval[int]{
+				{X: 1, Y: 2, Bounds: RightOpen},
+				{X: 3, Y: 4, Bounds: LeftOpen},
+				{X: 5, Y: 6, Bounds: Open},
+				{X: 7, Y: 8, Bounds: Closed},
+			},
+		},
+		{
+			input: "[1,2]\n[3,4]\r\n\t[5,6]\n",
+			expected: []Interval[int]{
+				{X: 1, Y: 2},
+				{X: 3, Y: 4},
+				{X: 5, Y: 6},
+			},
+		},
+		{
+			input: "[1,2], [3,4]",
+			expected: []Interval[int]{
+				{X: 1, Y: 2},
+				{X: 3, Y: 4},
+			},
+		},
+		{
+			input: "input in bad format",
+			error: errBadInput,
+		},
+		{
+			input: "[1,2][,]",
+			error: errBadInput,
+		},
+		{
+			input: "[1,]",
+			error: errBadInput,
+		},
+		{
+			input: "[,2]",
+			error: errBadInput,
+		},
+	}
+
+	for _, test := range testcases {
+		res, err := Parse(strings.NewReader(test.input), Int)
+		assert.Equal(t, test.expected, res, fmt.Sprintf("testcase: %v", test))
+		assert.ErrorIs(t, err, test.error, fmt.Sprintf("testcase: %v", test))
+	}
+}
+
+func TestFormat(t *testing.T) {
+	input := []Interval[int]{
+		{X: 1, Y: 2},
+		{X: 3, Y: 4},
+		{X: 5, Y: 6},
+	}
+	expected := "[1,2] [3,4] [5,6]"
+
+	assert.Equal(t, expected, Format(input, Int))
+
+	input = []Interval[int]{
+		{X: 1, Y: 2, Bounds: RightOpen},
+		{X: 3, Y: 4, Bounds: LeftOpen},
+		{X: 5, Y: 6, Bounds: Open},
+	}
+	expected = "[1,2) (3,4] (5,6)"
+
+	assert.Equal(t, expected, Format(input, Int))
+	assert.Equal(t, "", Format(nil, Int))
+}
+
+func TestKWayMerge(t *testing.T) {
+	testcases := []struct {
+		runs     []string
+		merge    MergeOptions
+		expected string
+	}{
+		{runs: []string{}, expected: ""},
+		{runs: []string{"[1,2] [5,6]"}, expected: "[1,2] [5,6]"},
+		{runs: []string{"[1,2] [5,6]", "[3,4] [7,8]"}, expected: "[1,2] [3,4] [5,6] [7,8]"},
+		{runs: []string{"[1,3] [9,10]", "[2,5]", "[4,6] [8,9]", ""}, expected: "[1,6] [8,10]"},
+		{runs: []string{"[1,2) [5,6]", "(2,3] [6,6]"}, expected: "[1,2) (2,3] [5,6]"},
+		{runs: []string{"[1,2] [5,6]", "[3,4] [7,8]"}, merge: MergeOptions{Adjacent: true}, expected: "[1,8]"},
+	}
+
+	for _, test := range testcases {
+		srcs := make([]source[int], len(test.runs))
+		for k, run := range test.runs {
+			srcs[k] = newDecoder(strings.NewReader(run), Int, ParseOptions{})
+		}
+
+		res := make([]Interval[int], 0)
+		err := kWayMerge(srcs, Int, test.merge, func(i Interval[int]) error {
+			res = append(res, i)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, Format(res, Int), fmt.Sprintf("testcase: %+v", test))
+	}
+}
+
+func TestProcessFile(t *testing.T) {
+	testcases := []struct {
+		expected    string
+		inputFile   string
+		maxFileSize int
+		fanIn       int
+		merge       MergeOptions
+	}{
+		{
+			expected:    "[1,3] [4,6] [7,8]",
+			inputFile:   "../data/simple_example.txt",
+			maxFileSize: 3,
+		},
+		{
+			expected:    "[1,3] [4,6] [7,8]",
+			inputFile:   "../data/simple_example.txt",
+			maxFileSize: 5,
+		},
+		{
+			expected:    "[1,3] [4,6] [7,8]",
+			inputFile:   "../data/simple_example.txt",
+			maxFileSize: 12,
+		},
+		{
+			expected:    "[1,3] [4,6] [7,8]",
+			inputFile:   "../data/simple_example.txt",
+			maxFileSize: 15,
+		},
+		{
+			expected:  "[2,23] [25,30]",
+			inputFile: "../data/coding_challenge.txt",
+		},
+		{
+			expected:  "[1,2) (2,5] (6,8]",
+			inputFile: "../data/half_open_example.txt",
+		},
+		{
+			expected:  "[1,8]",
+			inputFile: "../data/simple_example.txt",
+			merge:     MergeOptions{Adjacent: true},
+		},
+		{
+			expected:  "[2,23] [25,30]",
+			inputFile: "../data/coding_challenge.txt",
+			fanIn:     2,
+		},
+		{
+			expected:  "[1,2) (2,5] (6,8]",
+			inputFile: "../data/half_open_example.txt",
+			fanIn:     3,
+		},
+	}
+
+	for _, test := range testcases {
+		resFile, err := MergeFile(test.inputFile, Int, FileOptions{ChunkSize: 5, FanIn: test.fanIn, Workers: 4, Merge: test.merge, InMemoryThreshold: -1})
+		assert.NoError(t, err)
+
+		f, err := os.Open(resFile)
+		assert.NoError(t, err)
+		defer f.Close()
+
+		b, err := io.ReadAll(f)
+		assert.NoError(t, err)
+
+		b = bytes.Trim(b, "\n")
+
+		assert.Equal(t, test.expected, string(b))
+	}
+
+	t.Cleanup(func() {
+		assert.NoError(t, os.Remove(resultFileName))
+	})
+}
+
+func TestMergeStream(t *testing.T) {
+	testcases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "", expected: "\n"},
+		{input: "[1,3] [6,8] [2,4] [5,7]", expected: "[1,4] [5,8]\n"},
+		{input: "[25,30] [2,19]\n[14, 23] [4,8]\n", expected: "[2,23] [25,30]\n"},
+	}
+
+	for _, test := range testcases {
+		// hide any Seek method of the input
+		r := io.MultiReader(strings.NewReader(test.input))
+
+		var w bytes.Buffer
+		err := MergeStream(r, &w, Int, FileOptions{ChunkSize: 5, FanIn: 2, InMemoryThreshold: -1})
+		assert.NoError(t, err, fmt.Sprintf("testcase: %+v", test))
+		assert.Equal(t, test.expected, w.String(), fmt.Sprintf("testcase: %+v", test))
+	}
+
+	err := MergeStream(strings.NewReader("[1,2] [3,x]"), io.Discard, Int, FileOptions{})
+	assert.ErrorIs(t, err, errBadInput)
+
+	// an interval longer than the chunk size
+	long := "[1,2] [3," + strings.Repeat("9", 100) + "]"
+	err = MergeStream(strings.NewReader(long), io.Discard, BigInt, FileOptions{ChunkSize: 64, InMemoryThreshold: -1})
+	assert.ErrorIs(t, err, errBadInput)
+	assert.ErrorContains(t, err, "chunk size")
+}
+
+func TestMergeFileOutput(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "merged.txt")
+
+	res, err := MergeFile("../data/coding_challenge.txt", Int, FileOptions{Output: output})
+	assert.NoError(t, err)
+	assert.Equal(t, output, res)
+
+	b, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Equal(t, "[2,23] [25,30]\n", string(b))
+
+	// replaced by default
+	_, err = MergeFile("../data/simple_example.txt", Int, FileOptions{Output: output})
+	assert.NoError(t, err)
+
+	b, err = os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Equal(t, "[1,3] [4,6] [7,8]\n", string(b))
+
+	// kept with NoClobber
+	_, err = MergeFile("../data/coding_challenge.txt", Int, FileOptions{Output: output, NoClobber: true})
+	assert.ErrorIs(t, err, errOutputExists)
+
+	b, err = os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Equal(t, "[1,3] [4,6] [7,8]\n", string(b))
+
+	// no temporary files are left in the destination directory
+	entries, err := os.ReadDir(filepath.Dir(output))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestMergeFileTempDir(t *testing.T) {
+	tempDir := t.TempDir()
+	output := filepath.Join(t.TempDir(), "merged.txt")
+
+	_, err := MergeFile("../data/coding_challenge.txt", Int, FileOptions{Output: output, TempDir: tempDir, ChunkSize: 5, InMemoryThreshold: -1})
+	assert.NoError(t, err)
+
+	entries, err := os.ReadDir(tempDir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	_, err = MergeFile("../data/coding_challenge.txt", Int, FileOptions{Output: output, TempDir: filepath.Join(tempDir, "missing"), InMemoryThreshold: -1})
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestWriteResultNoClobber(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "merged.txt")
+
+	// the output appears while processing
+	assert.NoError(t, os.WriteFile(output, []byte("other"), 0o644))
+
+	_, err := writeResult(FileOptions{Output: output, NoClobber: true}, func(w io.Writer) error {
+		_, err := io.WriteString(w, "[1,2]\n")
+		return err
+	})
+	assert.ErrorIs(t, err, errOutputExists)
+
+	b, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Equal(t, "other", string(b))
+
+	entries, err := os.ReadDir(filepath.Dir(output))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestMergeStreamModes(t *testing.T) {
+	testcases := []struct {
+		input string
+		opts  FileOptions
+	}{
+		{input: ""},
+		{input: "[1,3] [6,8] [2,4] [5,7]"},
+		{input: "[25,30] [2,19]\n[14, 23] [4,8]\n"},
+		{input: "[3,4) (6,7) [1,2) [4,5] (2,3] [7,8]"},
+		{input: "[1,2] [3,4] [6,7]", opts: FileOptions{Merge: MergeOptions{Adjacent: true}}},
+		{input: "[5,1] (3,3) [7,8]", opts: FileOptions{Parse: ParseOptions{Reversed: SwapReversed, Empty: DropEmpty}}},
+	}
+
+	for _, test := range testcases {
+		mergeAs := func(threshold int64, e Encoding, spill Compression) string {
+			opts := test.opts
+			opts.InMemoryThreshold = threshold
+			opts.ChunkSize = 8
+			opts.Format.Encoding = e
+			opts.SpillCompression = spill
+
+			var w bytes.Buffer
+			err := MergeStream(io.MultiReader(strings.NewReader(test.input)), &w, Int, opts)
+			assert.NoError(t, err, fmt.Sprintf("testcase: %+v", test))
+			return w.String()
+		}
+		merge := func(threshold int64) string {
+			return mergeAs(threshold, Text, NoCompression)
+		}
+
+		expected := merge(-1)
+		size := int64(len(test.input))
+		assert.Equal(t, expected, merge(0), fmt.Sprintf("testcase: %+v", test))
+		assert.Equal(t, expected, merge(size), fmt.Sprintf("testcase: %+v", test))
+		if size > 0 {
+			assert.Equal(t, expected, merge(size-1), fmt.Sprintf("testcase: %+v", test))
+		}
+
+		// Binary results hold the number of intervals,
+		// also when copied from compressed runs
+		expected = mergeAs(0, Binary, NoCompression)
+		for _, spill := range []Compression{NoCompression, Gzip, Zstd} {
+			assert.Equal(t, expected, mergeAs(-1, Binary, spill), fmt.Sprintf("testcase: %+v, spill: %s", test, spill))
+		}
+	}
+
+	// small inputs need no temporary directory
+	var w bytes.Buffer
+	err := MergeStream(strings.NewReader("[1,3] [2,4]"), &w, Int, FileOptions{TempDir: filepath.Join(t.TempDir(), "missing")})
+	assert.NoError(t, err)
+	assert.Equal(t, "[1,4]\n", w.String())
+
+	err = MergeStream(strings.NewReader("[1,3] [2,4]"), &w, Int, FileOptions{TempDir: filepath.Join(t.TempDir(), "missing"), InMemoryThreshold: 5})
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestMergeFileParseErrorPosition(t *testing.T) {
+	var input strings.Builder
+	for n := 0; n < 200; n++ {
+		fmt.Fprintf(&input, "[%d,%d] ", n, n+1)
+		if n%7 == 0 {
+			input.WriteString("\n")
+		}
+	}
+	input.WriteString("\n  [7,x] [1,2]")
+
+	_, expected := Parse(strings.NewReader(input.String()), Int)
+	assert.Error(t, expected)
+
+	path := filepath.Join(t.TempDir(), "input.txt")
+	assert.NoError(t, os.WriteFile(path, []byte(input.String()), 0o644))
+
+	_, err := MergeFile(path, Int, FileOptions{Output: filepath.Join(t.TempDir(), "merged.txt"), ChunkSize: 64, Workers: 4, InMemoryThreshold: -1})
+	assert.ErrorIs(t, err, errBadInput)
+
+	var parseErr *ParseError
+	if assert.ErrorAs(t, err, &parseErr) {
+		assert.Equal(t, expected, parseErr)
+		assert.Equal(t, 201, parseErr.Interval)
+		assert.Equal(t, 31, parseErr.Line)
+		assert.Equal(t, 3, parseErr.Column)
+	}
+}
+
+func TestMergeFileSkipErrors(t *testing.T) {
+	var input strings.Builder
+	for n := 0; n < 200; n++ {
+		if n%9 == 0 {
+			fmt.Fprintf(&input, "[%d x] ", n)
+		} else {
+			fmt.Fprintf(&input, "[%d,%d] ", 2*n, 2*n+1)
+		}
+	}
+
+	var expected []int64
+	list, err := ParseWith(strings.NewReader(input.String()), Int, ParseOptions{OnError: SkipOnError, Reject: func(err *ParseError) {
+		expected = append(expected, err.Offset)
+	}})
+	assert.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "input.txt")
+	assert.NoError(t, os.WriteFile(path, []byte(input.String()), 0o644))
+
+	var offsets []int64
+	opts := FileOptions{
+		Output:            filepath.Join(t.TempDir(), "merged.txt"),
+		ChunkSize:         64,
+		Workers:           4,
+		InMemoryThreshold: -1,
+		Parse: ParseOptions{OnError: SkipOnError, Reject: func(err *ParseError) {
+			offsets = append(offsets, err.Offset)
+		}},
+	}
+	res, err := MergeFile(path, Int, opts)
+	assert.NoError(t, err)
+
+	b, err := os.ReadFile(res)
+	assert.NoError(t, err)
+	assert.Equal(t, Format(Merge(list, Int), Int)+"\n", string(b))
+
+	slices.Sort(offsets)
+	assert.Len(t, offsets, 23)
+	assert.Equal(t, expected, offsets)
+}
+
+func TestMergeStreamStrict(t *testing.T) {
+	// commas between intervals at chunk boundaries
+	input := "[1,2], [3,4],\n[5,6] ,(7,8)"
+	opts := FileOptions{ChunkSize: 64, InMemoryThreshold: -1, Parse: ParseOptions{Strict: true}}
+
+	var w bytes.Buffer
+	err := MergeStream(strings.NewReader(strings.Repeat(input+", ", 20)+input), &w, Int, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "[1,2] [3,4] [5,6] (7,8)\n", w.String())
+
+	err = MergeStream(strings.NewReader(strings.Repeat(input+", ", 20)+"[9,10]]"), io.Discard, Int, opts)
+	var parseErr *ParseError
+	assert.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, 20*4+2, parseErr.Interval)
+}
+
+func TestMergeStreamStrayBracket(t *testing.T) {
+	input := "[1,2]]" + strings.Repeat(" [3,4]", 20000)
+
+	for _, threshold := range []int64{0, -1} {
+		err := MergeStream(strings.NewReader(input), io.Discard, Int, FileOptions{InMemoryThreshold: threshold})
+		var parseErr *ParseError
+		if assert.ErrorAs(t, err, &parseErr, fmt.Sprintf("threshold %d", threshold)) {
+			assert.Equal(t, int64(5), parseErr.Offset, fmt.Sprintf("threshold %d", threshold))
+		}
+
+		// skipped as a single reject
+		var offsets []int64
+		var w bytes.Buffer
+		err = MergeStream(strings.NewReader(input), &w, Int, FileOptions{InMemoryThreshold: threshold, Parse: ParseOptions{OnError: SkipOnError, Reject: func(err *ParseError) {
+			offsets = append(offsets, err.Offset)
+		}}})
+		assert.NoError(t, err, fmt.Sprintf("threshold %d", threshold))
+		assert.Equal(t, "[1,2] [3,4]\n", w.String(), fmt.Sprintf("threshold %d", threshold))
+		assert.Equal(t, []int64{5}, offsets, fmt.Sprintf("threshold %d", threshold))
+	}
+}
+
+func TestSplitStream(t *testing.T) {
+	readRuns := func(runs []*os.File) []string {
+		res := make([]string, len(runs))
+		for k, f := range runs {
+			b, err := os.ReadFile(f.Name())
+			assert.NoError(t, err)
+			res[k] = string(b)
+		}
+		closeRuns(runs)
+		return res
+	}
+
+	tempDir := t.TempDir()
+	split := func(filePath string, workers int) ([]*os.File, error) {
+		f, err := os.Open(filePath)
+		assert.NoError(t, err)
+		defer f.Close()
+
+		return splitStream(f, tempDir, limits{chunkSize: 10, workers: workers}, Int, FileOptions{})
+	}
+
+	sequential, err := split("../data/coding_challenge.txt", 1)
+	assert.NoError(t, err)
+	expected := readRuns(sequential)
+	assert.Greater(t, len(expected), 1)
+
+	for n := 0; n < 10; n++ {
+		runs, err := split("../data/coding_challenge.txt", 4)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, readRuns(runs))
+	}
+
+	input := filepath.Join(t.TempDir(), "input.txt")
+	assert.NoError(t, os.WriteFile(input, []byte(strings.Repeat("[1,2] ", 100)+"[3,x] "+strings.Repeat("[1,2] ", 100)), 0o644))
+
+	runs, err := split(input, 4)
+	assert.ErrorIs(t, err, errBadInput)
+	assert.Nil(t, runs)
+
+	entries, err := os.ReadDir(tempDir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
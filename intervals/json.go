@@ -0,0 +1,453 @@
+package intervals
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// jsonDecoder parses intervals from a JSON array one at a
+// time with a token decoder, without holding more than a
+// single interval in memory. Elements may be encoded as
+// JSONArrays or JSONObjects.
+type jsonDecoder[T any] struct {
+	dec  *json.Decoder
+	in   *trackingReader
+	d    Domain[T]
+	opts ParseOptions
+
+	// whether the opening and the closing bracket
+	// of the array have been read
+	opened, closed bool
+}
+
+// newJSONDecoder returns a jsonDecoder reading intervals
+// with endpoints in domain d from r, validated according
+// to opts.
+func newJSONDecoder[T any](r io.Reader, d Domain[T], opts ParseOptions) *jsonDecoder[T] {
+	in := &trackingReader{r: r, pos: startPosition()}
+	dec := json.NewDecoder(in)
+	dec.UseNumber()
+
+	return &jsonDecoder[T]{dec: dec, in: in, d: d, opts: opts}
+}
+
+// next returns the next valid interval of the array.
+// Intervals dropped according to the parse options are
+// skipped. io.EOF is returned at the end of the array;
+// empty input is read as an empty array.
+func (dec *jsonDecoder[T]) next() (Interval[T], error) {
+	if !dec.opened {
+		t, err := dec.dec.Token()
+		switch {
+		case err == io.EOF:
+			dec.closed = true
+		case err != nil:
+			return Interval[T]{}, dec.readError(err)
+		case t != json.Delim('['):
+			return Interval[T]{}, dec.syntaxError(dec.dec.InputOffset(), "expected array of intervals")
+		}
+		dec.opened = true
+	}
+	if dec.closed {
+		return Interval[T]{}, io.EOF
+	}
+
+	for dec.dec.More() {
+		var raw json.RawMessage
+		err := dec.dec.Decode(&raw)
+		if err != nil {
+			return Interval[T]{}, dec.readError(err)
+		}
+
+		// skip the separator before the interval
+		end := dec.dec.InputOffset()
+		dec.in.advance(end - int64(len(raw)))
+		dec.in.pos.n++
+		pos := dec.in.pos
+		dec.in.advance(end)
+
+		i, ok, err := parseJSON(raw, dec.d, dec.opts)
+		if err != nil {
+			parseErr := newParseError(pos, string(raw), dec.in.peek(end+snippetContext), err)
+			if !dec.opts.skip(parseErr) {
+				return Interval[T]{}, parseErr
+			}
+			continue
+		}
+		if ok {
+			return i, nil
+		}
+	}
+
+	_, err := dec.dec.Token()
+	if err != nil {
+		return Interval[T]{}, dec.readError(err)
+	}
+	dec.closed = true
+	dec.in.advance(dec.dec.InputOffset())
+
+	// nothing but whitespace may follow the array
+	_, err = dec.dec.Token()
+	switch {
+	case err == nil:
+		return Interval[T]{}, dec.syntaxError(dec.dec.InputOffset(), "unexpected data after array")
+	case err != io.EOF:
+		return Interval[T]{}, dec.readError(err)
+	}
+
+	return Interval[T]{}, io.EOF
+}
+
+// parseJSON parses the interval in the JSON array or
+// object raw, with endpoints in domain d, and validates
+// it according to opts.
+//
+// It returns the interval and true, false if it has to be
+// dropped, or the reason why it is invalid.
+func parseJSON[T any](raw json.RawMessage, d Domain[T], opts ParseOptions) (Interval[T], bool, error) {
+	var start, end json.RawMessage
+	var bounds string
+
+	switch raw[0] {
+	case '[':
+		var elems []json.RawMessage
+		err := json.Unmarshal(raw, &elems)
+		if err != nil {
+			return Interval[T]{}, false, fmt.Errorf("%s: %w", err, errBadInput)
+		}
+		if len(elems) != 2 && len(elems) != 3 {
+			return Interval[T]{}, false, fmt.Errorf("expected 2 endpoints and optional bounds, got %d elements: %w", len(elems), errBadInput)
+		}
+
+		start, end = elems[0], elems[1]
+		if len(elems) == 3 {
+			err = json.Unmarshal(elems[2], &bounds)
+			if err != nil {
+				return Interval[T]{}, false, fmt.Errorf("bounds must be a string: %w", errBadInput)
+			}
+		}
+	case '{':
+		var obj struct {
+			Start  json.RawMessage `json:"start"`
+			End    json.RawMessage `json:"end"`
+			Bounds *string         `json:"bounds"`
+		}
+		err := json.Unmarshal(raw, &obj)
+		if err != nil {
+			return Interval[T]{}, false, fmt.Errorf("%s: %w", err, errBadInput)
+		}
+		if obj.Start == nil || obj.End == nil {
+			return Interval[T]{}, false, fmt.Errorf("missing start or end: %w", errBadInput)
+		}
+
+		start, end = obj.Start, obj.End
+		if obj.Bounds != nil {
+			bounds = *obj.Bounds
+		}
+	default:
+		return Interval[T]{}, false, fmt.Errorf("expected array or object: %w", errBadInput)
+	}
+
+	var i Interval[T]
+	switch bounds {
+	case "", "[]":
+	case "(]":
+		i.Bounds = LeftOpen
+	case "[)":
+		i.Bounds = RightOpen
+	case "()":
+		i.Bounds = Open
+	default:
+		return Interval[T]{}, false, fmt.Errorf("invalid bounds %q: %w", bounds, errBadInput)
+	}
+
+	var err error
+	i.X, err = jsonEndpoint(start, d)
+	if err != nil {
+		return Interval[T]{}, false, err
+	}
+
+	i.Y, err = jsonEndpoint(end, d)
+	if err != nil {
+		return Interval[T]{}, false, err
+	}
+
+	return validate(i, d, opts)
+}
+
+// jsonEndpoint converts the JSON number or string raw
+// with d.Parse. Numbers are converted from their text, so
+// that they are not limited to the range of float64.
+func jsonEndpoint[T any](raw json.RawMessage, d Domain[T]) (T, error) {
+	switch {
+	case raw[0] == '"':
+		var s string
+		err := json.Unmarshal(raw, &s)
+		if err != nil {
+			var zero T
+			return zero, fmt.Errorf("%s: %w", err, errBadInput)
+		}
+		return parseEndpoint(d, s)
+	case raw[0] == '-' || raw[0] >= '0' && raw[0] <= '9':
+		return parseEndpoint(d, string(raw))
+	default:
+		var zero T
+		return zero, fmt.Errorf("endpoint %s is neither a number nor a string: %w", raw, errBadInput)
+	}
+}
+
+// readError returns the error for err, an error of the
+// token decoder reading the array around the intervals.
+// Errors of the JSON syntax are returned as a *ParseError,
+// I/O errors as they are.
+func (dec *jsonDecoder[T]) readError(err error) error {
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return dec.syntaxError(syntaxErr.Offset, syntaxErr.Error())
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		return dec.syntaxError(dec.dec.InputOffset(), "unexpected end of input")
+	default:
+		return err
+	}
+}
+
+// syntaxError returns a *ParseError for invalid JSON
+// found before byte offset off, described by msg.
+// Decoding cannot go on after it, regardless of the
+// parse options.
+func (dec *jsonDecoder[T]) syntaxError(off int64, msg string) error {
+	pos := dec.in.pos
+	pos.n++
+
+	// point past the separator after the last interval
+	b := dec.in.peek(off)
+	t := bytes.TrimLeft(b, ","+space)
+	pos.advance(b[:len(b)-len(t)])
+
+	after := dec.in.peek(off + snippetContext)[len(b):]
+	return newParseError(pos, string(t), after, fmt.Errorf("invalid JSON: %s: %w", msg, errBadInput))
+}
+
+// jsonEncoder writes intervals as a JSON array, or as
+// NDJSON, one at a time, without holding the list in
+// memory.
+type jsonEncoder[T any] struct {
+	w *bufio.Writer
+	d Domain[T]
+	// whether intervals are written as JSONObjects
+	// instead of JSONArrays
+	objects bool
+	// whether intervals are written one per line
+	// instead of in an array
+	lines bool
+	n     int
+}
+
+// encode writes interval i, preceded by the opening
+// bracket of the array or a comma - or, one per line,
+// by a newline unless it is the first one.
+func (enc *jsonEncoder[T]) encode(i Interval[T]) error {
+	var err error
+	switch {
+	case enc.lines && enc.n > 0:
+		err = enc.w.WriteByte('\n')
+	case enc.lines:
+	case enc.n > 0:
+		err = enc.w.WriteByte(',')
+	default:
+		err = enc.w.WriteByte('[')
+	}
+	if err != nil {
+		return err
+	}
+	enc.n++
+
+	x, y := jsonValue(enc.d.Format(i.X)), jsonValue(enc.d.Format(i.Y))
+	var s string
+	switch {
+	case enc.objects && i.Bounds == Closed:
+		s = fmt.Sprintf(`{"start":%s,"end":%s}`, x, y)
+	case enc.objects:
+		s = fmt.Sprintf(`{"start":%s,"end":%s,"bounds":"%s"}`, x, y, boundsText(i.Bounds))
+	case i.Bounds == Closed:
+		s = fmt.Sprintf(`[%s,%s]`, x, y)
+	default:
+		s = fmt.Sprintf(`[%s,%s,"%s"]`, x, y, boundsText(i.Bounds))
+	}
+
+	_, err = enc.w.WriteString(s)
+	return err
+}
+
+// flush closes the array and writes any buffered data
+// to the underlying writer.
+func (enc *jsonEncoder[T]) flush() error {
+	var err error
+	switch {
+	case enc.lines:
+	case enc.n == 0:
+		_, err = enc.w.WriteString("[]")
+	default:
+		err = enc.w.WriteByte(']')
+	}
+	if err != nil {
+		return err
+	}
+
+	return enc.w.Flush()
+}
+
+// jsonValue returns endpoint text s as a JSON value: s
+// itself if it is a JSON number, or else a JSON string.
+func jsonValue(s string) string {
+	if s != "" && (s[0] == '-' || s[0] >= '0' && s[0] <= '9') && json.Valid([]byte(s)) {
+		return s
+	}
+
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// trackingReader keeps the position of the input consumed
+// by a decoder that reads ahead of it, e.g. json.Decoder.
+type trackingReader struct {
+	r io.Reader
+	// input read, but not consumed yet
+	buf []byte
+	// position of buf[0]
+	pos position
+}
+
+func (t *trackingReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	t.buf = append(t.buf, p[:n]...)
+	return n, err
+}
+
+// advance consumes the input up to byte offset off.
+func (t *trackingReader) advance(off int64) {
+	b := t.peek(off)
+	t.pos.advance(b)
+	t.buf = t.buf[len(b):]
+}
+
+// peek returns the input from pos up to byte offset off,
+// as far as it has been read.
+func (t *trackingReader) peek(off int64) []byte {
+	k := off - t.pos.offset
+	if k > int64(len(t.buf)) {
+		k = int64(len(t.buf))
+	}
+	return t.buf[:k]
+}
+
+// ndjsonDecoder parses intervals from NDJSON input one
+// line at a time, without holding more than a single
+// interval in memory. Blank lines are skipped.
+type ndjsonDecoder[T any] struct {
+	scanner *bufio.Scanner
+	d       Domain[T]
+	opts    ParseOptions
+
+	// position of the next line in the input, and the
+	// input following the last one
+	pos   position
+	after lookahead
+}
+
+// newNDJSONDecoder returns an ndjsonDecoder reading
+// intervals with endpoints in domain d from r, validated
+// according to opts. The input starts at pos within a
+// larger input, e.g. a chunk of a file.
+func newNDJSONDecoder[T any](r io.Reader, d Domain[T], opts ParseOptions, pos position) *ndjsonDecoder[T] {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, DefaultChunkSize)
+	dec := &ndjsonDecoder[T]{scanner: scanner, d: d, opts: opts, pos: pos}
+	scanner.Split(dec.after.split(scanLines))
+	return dec
+}
+
+// next returns the next valid interval of the input.
+// Intervals dropped according to the parse options are
+// skipped. io.EOF is returned at the end of the input.
+func (dec *ndjsonDecoder[T]) next() (Interval[T], error) {
+	for dec.scanner.Scan() {
+		line := dec.scanner.Bytes()
+		pos := dec.pos
+		dec.pos.advance(line)
+
+		raw := bytes.TrimSpace(line)
+		if len(raw) == 0 {
+			continue
+		}
+		// point at the interval rather than the line
+		pos.advance(line[:len(line)-len(bytes.TrimLeft(line, space))])
+		pos.n++
+		dec.pos.n++
+
+		var i Interval[T]
+		ok := false
+		var v json.RawMessage
+		err := json.Unmarshal(raw, &v)
+		if err != nil {
+			err = fmt.Errorf("invalid JSON: %s: %w", err, errBadInput)
+		} else {
+			i, ok, err = parseJSON(raw, dec.d, dec.opts)
+		}
+		if err != nil {
+			parseErr := newParseError(pos, string(raw), dec.after.bytes(), err)
+			if !dec.opts.skip(parseErr) {
+				return Interval[T]{}, parseErr
+			}
+			continue
+		}
+		if ok {
+			return i, nil
+		}
+	}
+
+	if err := dec.scanner.Err(); err != nil {
+		return Interval[T]{}, err
+	}
+
+	return Interval[T]{}, io.EOF
+}
+
+// scanLines is a bufio.SplitFunc returning each line of
+// the input together with its newline, so that positions
+// can be tracked exactly.
+func scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if k := bytes.IndexByte(data, '\n'); k >= 0 {
+		return k + 1, data[:k+1], nil
+	}
+
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+
+	return 0, nil, nil
+}
+
+// countLines returns the number of lines in data
+// that are not blank.
+func countLines(data []byte) int {
+	n := 0
+	for len(data) > 0 {
+		line := data
+		if k := bytes.IndexByte(data, '\n'); k >= 0 {
+			line, data = data[:k], data[k+1:]
+		} else {
+			data = nil
+		}
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,229 @@
+package intervals
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseJSON(t *testing.T) {
+	testcases := []struct {
+		input    string
+		expected string
+		err      string
+	}{
+		{input: "", expected: ""},
+		{input: " [ ]\n", expected: ""},
+		{input: "[[1,2],[3,4]]", expected: "[1,2] [3,4]"},
+		{input: `[[1,2,"(]"], [3, 4, "[)"], [5,6,"()"], [7,8,"[]"]]`, expected: "(1,2] [3,4) (5,6) [7,8]"},
+		{input: `[{"start":1,"end":2}, {"end":4,"start":3,"bounds":"(]","id":"x"}]`, expected: "[1,2] (3,4]"},
+		{input: `[[1,2], {"start":"-3","end":"+4"}]`, expected: "[1,2] [-3,4]"},
+		{input: "[[1,2]", err: "invalid JSON"},
+		{input: "[[1,2]] [[3,4]]", err: "unexpected data after array"},
+		{input: `{"start":1,"end":2}`, err: "expected array of intervals"},
+		{input: "[[1,2,3,4]]", err: "expected 2 endpoints and optional bounds, got 4 elements"},
+		{input: `[[1,2,"<>"]]`, err: `invalid bounds "<>"`},
+		{input: "[[1,2,3]]", err: "bounds must be a string"},
+		{input: `[{"start":1}]`, err: "missing start or end"},
+		{input: "[[null,2]]", err: "endpoint null is neither a number nor a string"},
+		{input: "[[1.5,2]]", err: `failed to convert "1.5" to endpoint`},
+		{input: "[[1,9223372036854775808]]", err: "value out of range"},
+		{input: "[[5,1]]", err: "left endpoint is greater than right endpoint"},
+		{input: "[1,2]", err: "expected array or object"},
+	}
+
+	for _, test := range testcases {
+		res, err := ParseWith(strings.NewReader(test.input), Int64, ParseOptions{Encoding: JSONArrays})
+		if test.err != "" {
+			assert.ErrorIs(t, err, errBadInput, fmt.Sprintf("testcase: %+v", test))
+			assert.ErrorContains(t, err, test.err, fmt.Sprintf("testcase: %+v", test))
+			continue
+		}
+
+		assert.NoError(t, err, fmt.Sprintf("testcase: %+v", test))
+		assert.Equal(t, test.expected, Format(res, Int64), fmt.Sprintf("testcase: %+v", test))
+	}
+}
+
+func TestParseJSONErrorPosition(t *testing.T) {
+	_, err := ParseWith(strings.NewReader("[\n  [1,2],\n  [3,x]\n]"), Int, ParseOptions{Encoding: JSONObjects})
+
+	var parseErr *ParseError
+	assert.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, 2, parseErr.Interval)
+	assert.Equal(t, int64(13), parseErr.Offset)
+	assert.Equal(t, 3, parseErr.Line)
+	assert.Equal(t, 3, parseErr.Column)
+	assert.Equal(t, "[3,x", parseErr.Text)
+
+	var rejects []string
+	opts := ParseOptions{Encoding: JSONArrays, OnError: SkipOnError, Reject: func(err *ParseError) {
+		rejects = append(rejects, fmt.Sprintf("%d %d %s", err.Interval, err.Offset, err.Text))
+	}}
+	res, err := ParseWith(strings.NewReader(`[[1,2], [3,"x"], [5,4],  [6,7]]`), Int, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "[1,2] [6,7]", Format(res, Int))
+	assert.Equal(t, []string{`2 8 [3,"x"]`, "3 17 [5,4]"}, rejects)
+}
+
+func TestFormatWith(t *testing.T) {
+	list := []Interval[int]{{X: -1, Y: 2}, {X: 3, Y: 4, Bounds: RightOpen}, {X: 5, Y: 6, Bounds: Open}}
+
+	testcases := []struct {
+		list     []Interval[int]
+		encoding Encoding
+		expected string
+	}{
+		{list: nil, encoding: Text, expected: ""},
+		{list: nil, encoding: JSONArrays, expected: "[]"},
+		{list: nil, encoding: JSONObjects, expected: "[]"},
+		{list: list, encoding: Text, expected: "[-1,2] [3,4) (5,6)"},
+		{list: list, encoding: JSONArrays, expected: `[[-1,2],[3,4,"[)"],[5,6,"()"]]`},
+		{list: list, encoding: JSONObjects, expected: `[{"start":-1,"end":2},{"start":3,"end":4,"bounds":"[)"},{"start":5,"end":6,"bounds":"()"}]`},
+		{list: nil, encoding: Lines, expected: ""},
+		{list: nil, encoding: NDJSON, expected: ""},
+		{list: list, encoding: Lines, expected: "[-1,2]\n[3,4)\n(5,6)"},
+		{list: list, encoding: NDJSON, expected: "{\"start\":-1,\"end\":2}\n{\"start\":3,\"end\":4,\"bounds\":\"[)\"}\n{\"start\":5,\"end\":6,\"bounds\":\"()\"}"},
+	}
+
+	for _, test := range testcases {
+		s := FormatWith(test.list, Int, FormatOptions{Encoding: test.encoding})
+		assert.Equal(t, test.expected, s, fmt.Sprintf("testcase: %+v", test))
+
+		parsed, err := ParseWith(strings.NewReader(s), Int, ParseOptions{Encoding: test.encoding})
+		assert.NoError(t, err, fmt.Sprintf("testcase: %+v", test))
+		assert.Equal(t, len(test.list), len(parsed), fmt.Sprintf("testcase: %+v", test))
+	}
+}
+
+func TestFormatJSONStrings(t *testing.T) {
+	list, err := Parse(strings.NewReader("[2023-08-01T08:00:00Z,2023-08-01T12:00:00Z]"), Time)
+	assert.NoError(t, err)
+	assert.Equal(t, `[["2023-08-01T08:00:00Z","2023-08-01T12:00:00Z"]]`, FormatWith(list, Time, FormatOptions{Encoding: JSONArrays}))
+
+	floats := []Interval[float64]{{X: -1e300, Y: 1e21}}
+	assert.Equal(t, `[{"start":-1e+300,"end":1e+21}]`, FormatWith(floats, Float64, FormatOptions{Encoding: JSONObjects}))
+}
+
+func TestMergeStreamJSON(t *testing.T) {
+	var input bytes.Buffer
+	input.WriteString("[")
+	for k := 0; k < 100; k++ {
+		if k > 0 {
+			input.WriteString(",\n")
+		}
+		fmt.Fprintf(&input, `{"start":%d,"end":%d}`, 1000-10*k, 1000-10*k+5)
+	}
+	input.WriteString("]")
+
+	for _, threshold := range []int64{0, -1} {
+		opts := FileOptions{
+			ChunkSize:         64,
+			FanIn:             3,
+			InMemoryThreshold: threshold,
+			Parse:             ParseOptions{Encoding: JSONObjects},
+			Format:            FormatOptions{Encoding: JSONArrays},
+		}
+
+		var w bytes.Buffer
+		err := MergeStream(bytes.NewReader(input.Bytes()), &w, Int, opts)
+		assert.NoError(t, err, fmt.Sprintf("threshold %d", threshold))
+
+		res, err := ParseWith(&w, Int, opts.Parse)
+		assert.NoError(t, err, fmt.Sprintf("threshold %d", threshold))
+		assert.Equal(t, 100, len(res), fmt.Sprintf("threshold %d", threshold))
+		assert.Equal(t, Interval[int]{X: 10, Y: 15}, res[0], fmt.Sprintf("threshold %d", threshold))
+	}
+
+	// errors point into the whole input, not the chunk
+	prefix := strings.TrimSuffix(input.String(), "]")
+	err := MergeStream(strings.NewReader(prefix+`,[1,"x"]]`), io.Discard, Int, FileOptions{
+		ChunkSize:         64,
+		InMemoryThreshold: -1,
+		Parse:             ParseOptions{Encoding: JSONArrays},
+	})
+	var parseErr *ParseError
+	assert.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, 101, parseErr.Interval)
+	assert.Equal(t, int64(len(prefix)+1), parseErr.Offset)
+}
+
+func TestParseNDJSON(t *testing.T) {
+	testcases := []struct {
+		input    string
+		expected string
+		err      string
+	}{
+		{input: "", expected: ""},
+		{input: "\n \n", expected: ""},
+		{input: "{\"start\":1,\"end\":2}\n[3,4,\"(]\"]\r\n\n[5,6]", expected: "[1,2] (3,4] [5,6]"},
+		{input: "[1,2] [3,4]\n", err: "invalid JSON: invalid character '[' after top-level value"},
+		{input: "[[1,2]]\n", err: "expected 2 endpoints"},
+		{input: "[1,2]\n{\"start\":1\n", err: "invalid JSON"},
+	}
+
+	for _, test := range testcases {
+		res, err := ParseWith(strings.NewReader(test.input), Int, ParseOptions{Encoding: NDJSON})
+		if test.err != "" {
+			assert.ErrorIs(t, err, errBadInput, fmt.Sprintf("testcase: %+v", test))
+			assert.ErrorContains(t, err, test.err, fmt.Sprintf("testcase: %+v", test))
+			continue
+		}
+
+		assert.NoError(t, err, fmt.Sprintf("testcase: %+v", test))
+		assert.Equal(t, test.expected, Format(res, Int), fmt.Sprintf("testcase: %+v", test))
+	}
+
+	_, err := ParseWith(strings.NewReader("[1,2]\n\n  [3,x]\n"), Int, ParseOptions{Encoding: NDJSON})
+	var parseErr *ParseError
+	assert.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, 2, parseErr.Interval)
+	assert.Equal(t, int64(9), parseErr.Offset)
+	assert.Equal(t, 3, parseErr.Line)
+	assert.Equal(t, 3, parseErr.Column)
+}
+
+func TestMergeStreamLines(t *testing.T) {
+	for _, encoding := range []Encoding{Lines, NDJSON} {
+		list := make([]Interval[int], 100)
+		for k := range list {
+			list[k] = Interval[int]{X: 1000 - 10*k, Y: 1000 - 10*k + 5}
+		}
+		input := FormatWith(list, Int, FormatOptions{Encoding: encoding}) + "\n"
+
+		for _, threshold := range []int64{0, -1} {
+			opts := FileOptions{
+				ChunkSize:         64,
+				FanIn:             3,
+				InMemoryThreshold: threshold,
+				Parse:             ParseOptions{Encoding: encoding},
+				Format:            FormatOptions{Encoding: encoding},
+			}
+
+			var w bytes.Buffer
+			err := MergeStream(strings.NewReader(input), &w, Int, opts)
+			assert.NoError(t, err, fmt.Sprintf("encoding %d, threshold %d", encoding, threshold))
+			assert.Equal(t, 100, strings.Count(w.String(), "\n"), fmt.Sprintf("encoding %d, threshold %d", encoding, threshold))
+
+			res, err := ParseWith(&w, Int, opts.Parse)
+			assert.NoError(t, err, fmt.Sprintf("encoding %d, threshold %d", encoding, threshold))
+			assert.Equal(t, 100, len(res), fmt.Sprintf("encoding %d, threshold %d", encoding, threshold))
+			assert.Equal(t, Interval[int]{X: 10, Y: 15}, res[0], fmt.Sprintf("encoding %d, threshold %d", encoding, threshold))
+		}
+
+		// errors point into the whole input, not the chunk
+		err := MergeStream(strings.NewReader(input+"[1,x]\n"), io.Discard, Int, FileOptions{
+			ChunkSize:         64,
+			InMemoryThreshold: -1,
+			Parse:             ParseOptions{Encoding: encoding},
+		})
+		var parseErr *ParseError
+		assert.ErrorAs(t, err, &parseErr, fmt.Sprintf("encoding %d", encoding))
+		assert.Equal(t, 101, parseErr.Interval, fmt.Sprintf("encoding %d", encoding))
+		assert.Equal(t, int64(len(input)), parseErr.Offset, fmt.Sprintf("encoding %d", encoding))
+	}
+}
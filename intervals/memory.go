@@ -0,0 +1,150 @@
+package intervals
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+const (
+	// minIntervalText is the length of the shortest
+	// interval in the input, e.g. "[1,2]".
+	minIntervalText = 5
+
+	// ioBufferSize is the size of the buffers of the
+	// encoders and decoders of intermediate files.
+	ioBufferSize = 4096
+
+	// copyBufferSize is the size of the buffer io.Copy
+	// uses when writing the result.
+	copyBufferSize = 32 * 1024
+
+	// runOverhead is the memory used per run while merging
+	// besides its read buffer and next interval: decoder,
+	// file and heap entry.
+	runOverhead = 256
+)
+
+var errMemoryTooSmall = errors.New("memory budget too small")
+
+// limits are the sizes file processing works with.
+type limits struct {
+	chunkSize int
+	workers   int
+	fanIn     int
+}
+
+// limits returns the chunk size, number of workers and
+// fan-in to use for intervals of intervalSize bytes in
+// memory.
+//
+// Without opts.MaxMemory, they are taken from opts.
+// Otherwise they are reduced as needed, such that each
+// phase fits in opts.MaxMemory:
+//
+//   - splitting: the scanner buffer, or for encodings
+//     decoded before splitting up to twice the intervals of
+//     the chunk being read, and per worker a copy of its
+//     chunk, up to twice the intervals parsed from it, as
+//     appending grows the slice, and an encoder.
+//   - merging: per run a decoder with its next interval,
+//     and an encoder.
+//   - writing the result: a copy buffer and a writer.
+//
+// Encoders and decoders of temporary files include a
+// compressor or decompressor with opts.SpillCompression,
+// and the writer of the result one with opts.Compression.
+// The decompressor of the input is not accounted for.
+//
+// An error wrapping errMemoryTooSmall is returned if a
+// phase cannot make progress within opts.MaxMemory.
+func (opts FileOptions) limits(intervalSize int) (limits, error) {
+	l := limits{chunkSize: opts.chunkSize(), workers: opts.workers(), fanIn: opts.fanIn()}
+	if opts.MaxMemory <= 0 {
+		return l, nil
+	}
+
+	budget := opts.MaxMemory
+	spill := opts.SpillCompression
+	decoded := opts.Parse.Encoding.decoded()
+	if min := minMemory(intervalSize, spill, opts.Compression, decoded); budget < min {
+		return limits{}, fmt.Errorf("%w: %d bytes, at least %d bytes are needed", errMemoryTooSmall, budget, min)
+	}
+
+	// fewer workers leave room for larger chunks
+	for ; l.workers > 1; l.workers-- {
+		if maxChunkSize(budget, l.workers, intervalSize, spill, decoded) >= minBufferSize {
+			break
+		}
+	}
+	if chunkSize := maxChunkSize(budget, l.workers, intervalSize, spill, decoded); opts.ChunkSize <= 0 || chunkSize < int64(opts.ChunkSize) {
+		l.chunkSize = int(chunkSize)
+	}
+
+	if fanIn := maxFanIn(budget, intervalSize, spill); fanIn < int64(l.fanIn) {
+		l.fanIn = int(fanIn)
+	}
+
+	return l, nil
+}
+
+// intervalSize returns the size in bytes of an interval
+// with endpoints of type T in memory.
+func intervalSize[T any]() int {
+	return int(unsafe.Sizeof(Interval[T]{}))
+}
+
+// maxChunkSize returns the largest chunk size for which
+// splitting with workers, writing with compression c,
+// fits in budget bytes. If decoded, the chunk being read
+// is held as intervals rather than as text.
+func maxChunkSize(budget int64, workers int, intervalSize int, c Compression, decoded bool) int64 {
+	// budget = read + workers * (chunk + 2*chunk/minIntervalText*intervalSize + encoder)
+	// with read = chunk, or 2*chunk/minIntervalText*intervalSize if decoded
+	read := int64(minIntervalText)
+	if decoded {
+		read = 2 * int64(intervalSize)
+	}
+
+	w := int64(workers)
+	free := budget - w*(ioBufferSize+c.memory())
+	if free <= 0 {
+		return 0
+	}
+	return free * minIntervalText / (read + w*(minIntervalText+2*int64(intervalSize)))
+}
+
+// maxFanIn returns the largest fan-in for which merging
+// runs compressed with c fits in budget bytes.
+func maxFanIn(budget int64, intervalSize int, c Compression) int64 {
+	stream := ioBufferSize + c.memory()
+	return (budget - stream) / (stream + int64(intervalSize) + runOverhead)
+}
+
+// minMemory returns the smallest budget in bytes with which
+// each phase can make progress: splitting with a single
+// worker and chunks of minBufferSize bytes, merging two runs
+// at a time and writing the result, with runs compressed
+// with spill and the result with result. If decoded, the
+// chunk being read is held as intervals rather than as
+// text.
+func minMemory(intervalSize int, spill, result Compression, decoded bool) int64 {
+	stream := ioBufferSize + spill.memory()
+	intervals := 2 * (minBufferSize/minIntervalText + 1) * int64(intervalSize)
+	read := int64(minBufferSize)
+	if decoded {
+		read = intervals
+	}
+	split := read + (minBufferSize + intervals + stream)
+	merge := stream + 2*(stream+int64(intervalSize)+runOverhead)
+	write := copyBufferSize + ioBufferSize + spill.memory() + result.memory()
+
+	res := split
+	if merge > res {
+		res = merge
+	}
+	if write > res {
+		res = write
+	}
+	return res
+}
@@ -0,0 +1,82 @@
+package intervals
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimits(t *testing.T) {
+	size := intervalSize[int]()
+
+	l, err := FileOptions{Workers: 4}.limits(size)
+	assert.NoError(t, err)
+	assert.Equal(t, limits{chunkSize: DefaultChunkSize, workers: 4, fanIn: DefaultFanIn}, l)
+
+	testcases := []struct {
+		opts FileOptions
+	}{
+		{opts: FileOptions{MaxMemory: minMemory(size, NoCompression, NoCompression, false), Workers: 32}},
+		{opts: FileOptions{MaxMemory: 1 << 20, Workers: 32}},
+		{opts: FileOptions{MaxMemory: 1 << 20, Workers: 32, FanIn: 1000}},
+		{opts: FileOptions{MaxMemory: 1 << 30, Workers: 32, ChunkSize: 4096, FanIn: 8}},
+		{opts: FileOptions{MaxMemory: minMemory(size, NoCompression, NoCompression, true), Workers: 1, Parse: ParseOptions{Encoding: JSONArrays}}},
+		{opts: FileOptions{MaxMemory: 1 << 20, Workers: 1, Parse: ParseOptions{Encoding: Binary}}},
+		{opts: FileOptions{MaxMemory: 1 << 20, Workers: 32, Parse: ParseOptions{Encoding: CSV}}},
+	}
+
+	for _, test := range testcases {
+		l, err := test.opts.limits(size)
+		assert.NoError(t, err, fmt.Sprintf("testcase: %+v", test))
+
+		assert.GreaterOrEqual(t, l.chunkSize, minBufferSize, fmt.Sprintf("testcase: %+v", test))
+		assert.GreaterOrEqual(t, l.workers, 1, fmt.Sprintf("testcase: %+v", test))
+		assert.GreaterOrEqual(t, l.fanIn, 2, fmt.Sprintf("testcase: %+v", test))
+		if test.opts.ChunkSize > 0 {
+			assert.LessOrEqual(t, l.chunkSize, test.opts.ChunkSize, fmt.Sprintf("testcase: %+v", test))
+		}
+		if test.opts.FanIn >= 2 {
+			assert.LessOrEqual(t, l.fanIn, test.opts.FanIn, fmt.Sprintf("testcase: %+v", test))
+		}
+
+		// the chunk being read is held as text, or as intervals
+		chunk, workers := int64(l.chunkSize), int64(l.workers)
+		read := chunk
+		if test.opts.Parse.Encoding.decoded() {
+			read = 2 * chunk / minIntervalText * int64(size)
+		}
+		split := read + workers*(chunk+2*chunk/minIntervalText*int64(size)+ioBufferSize)
+		merge := ioBufferSize + int64(l.fanIn)*(ioBufferSize+int64(size)+runOverhead)
+		assert.LessOrEqual(t, split, test.opts.MaxMemory, fmt.Sprintf("testcase: %+v", test))
+		assert.LessOrEqual(t, merge, test.opts.MaxMemory, fmt.Sprintf("testcase: %+v", test))
+	}
+
+	// larger intervals leave room for less of them
+	small, err := FileOptions{MaxMemory: 1 << 20, Workers: 1}.limits(intervalSize[int]())
+	assert.NoError(t, err)
+	large, err := FileOptions{MaxMemory: 1 << 20, Workers: 1}.limits(intervalSize[time.Time]())
+	assert.NoError(t, err)
+	assert.Less(t, large.chunkSize, small.chunkSize)
+
+	// as do intervals decoded before splitting
+	decoded, err := FileOptions{MaxMemory: 1 << 20, Workers: 1, Parse: ParseOptions{Encoding: Binary}}.limits(intervalSize[int]())
+	assert.NoError(t, err)
+	assert.Less(t, decoded.chunkSize, small.chunkSize)
+
+	_, err = FileOptions{MaxMemory: minMemory(size, NoCompression, NoCompression, false) - 1}.limits(size)
+	assert.ErrorIs(t, err, errMemoryTooSmall)
+}
+
+func TestMergeFileMaxMemory(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "merged.txt")
+
+	_, err := MergeFile("../data/coding_challenge.txt", Int, FileOptions{Output: output, MaxMemory: 1024})
+	assert.ErrorIs(t, err, errMemoryTooSmall)
+
+	res, err := MergeFile("../data/coding_challenge.txt", Int, FileOptions{Output: output, MaxMemory: minMemory(intervalSize[int](), NoCompression, NoCompression, false)})
+	assert.NoError(t, err)
+	assert.Equal(t, output, res)
+}
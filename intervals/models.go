@@ -0,0 +1,520 @@
+package intervals
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	errBadInput = errors.New("bad input")
+
+	// ErrOutOfRange is the reason of a ParseError for an
+	// endpoint that is a valid number, but out of the range
+	// of the endpoint type, e.g. 2^63 for Int64. Use BigInt
+	// for integers of arbitrary size.
+	ErrOutOfRange = errors.New("value out of range")
+)
+
+// Interval is an interval between the endpoints X and Y
+// of type T. The Domain of T defines how endpoints
+// are ordered.
+//
+// Bounds defines whether the endpoints belong to the
+// interval. The zero value is a closed interval [X,Y].
+type Interval[T any] struct {
+	X      T
+	Y      T
+	Bounds Bounds
+}
+
+// Bounds describes which endpoints of an interval are
+// excluded from it.
+type Bounds uint8
+
+const (
+	// Closed intervals [X,Y] include both endpoints.
+	Closed Bounds = 0
+	// LeftOpen intervals (X,Y] exclude X.
+	LeftOpen Bounds = 1 << 0
+	// RightOpen intervals [X,Y) exclude Y.
+	RightOpen Bounds = 1 << 1
+	// Open intervals (X,Y) exclude both endpoints.
+	Open = LeftOpen | RightOpen
+)
+
+// leftOpen reports whether X is excluded from the interval.
+func (i Interval[T]) leftOpen() bool {
+	return i.Bounds&LeftOpen != 0
+}
+
+// rightOpen reports whether Y is excluded from the interval.
+func (i Interval[T]) rightOpen() bool {
+	return i.Bounds&RightOpen != 0
+}
+
+// compareLeft orders intervals by their left endpoint.
+// For equal endpoints, an interval including X begins
+// before an interval excluding it.
+func compareLeft[T any](a, b Interval[T], cmp func(a, b T) int) int {
+	if c := cmp(a.X, b.X); c != 0 {
+		return c
+	}
+
+	switch {
+	case !a.leftOpen() && b.leftOpen():
+		return -1
+	case a.leftOpen() && !b.leftOpen():
+		return 1
+	default:
+		return 0
+	}
+}
+
+// compareRight orders intervals by their right endpoint.
+// For equal endpoints, an interval excluding Y ends
+// before an interval including it.
+func compareRight[T any](a, b Interval[T], cmp func(a, b T) int) int {
+	if c := cmp(a.Y, b.Y); c != 0 {
+		return c
+	}
+
+	switch {
+	case a.rightOpen() && !b.rightOpen():
+		return -1
+	case !a.rightOpen() && b.rightOpen():
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Parse scans through the reader one interval at a time,
+// parsing it into an Interval. Endpoints are converted
+// with d.Parse.
+//
+// Square brackets include an endpoint, parentheses
+// exclude it: [1,2] is closed, [1,2) is right-open,
+// (1,2] is left-open and (1,2) is open.
+// Reversed intervals, e.g. [5,1], are rejected.
+// A slice with all intervals in the reader and an empty
+// error will be returned upon success.
+// Any ocurring parsing errors will be returned
+// with an empty slice.
+func Parse[T any](r io.Reader, d Domain[T]) ([]Interval[T], error) {
+	return ParseWith(r, d, ParseOptions{})
+}
+
+// ParseWith parses intervals like Parse, in the encoding
+// of opts, validating each interval according to opts.
+// Intervals that are rejected by opts result in a
+// *ParseError, as do intervals that cannot be parsed.
+func ParseWith[T any](r io.Reader, d Domain[T], opts ParseOptions) ([]Interval[T], error) {
+	return parseAll(newSource(r, d, opts))
+}
+
+// parseAll reads all intervals from src.
+func parseAll[T any](src source[T]) ([]Interval[T], error) {
+	res := make([]Interval[T], 0)
+	for {
+		i, err := src.next()
+		if err == io.EOF {
+			return res, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, i)
+	}
+}
+
+// ParseError describes an interval in the input that
+// cannot be parsed, or that is rejected by the parse
+// options. It matches errBadInput with errors.Is, as well
+// as the reason in Err.
+type ParseError struct {
+	// Interval is the ordinal number of the interval
+	// in the input, starting at 1.
+	Interval int
+
+	// Offset is the byte offset of the interval in the
+	// input, starting at 0.
+	Offset int64
+
+	// Line and Column are the position of the interval in
+	// the input, starting at 1. Columns count bytes. Both
+	// are 0 for the Binary encoding, which has no lines.
+	Line   int
+	Column int
+
+	// Text is the interval as found in the input.
+	Text string
+
+	// Snippet is the interval together with the input
+	// around it: up to 20 bytes before and after it, as
+	// far as read along with the interval. In file mode,
+	// the input after it ends with its chunk.
+	Snippet string
+
+	// Err is the reason the interval was rejected, e.g.
+	// ErrOutOfRange.
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("invalid interval %d %q at offset %d: %s", e.Interval, e.Text, e.Offset, e.Err)
+	}
+	return fmt.Sprintf("invalid interval %d %q at line %d, column %d (offset %d): %s", e.Interval, e.Text, e.Line, e.Column, e.Offset, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is errBadInput: every
+// ParseError is caused by bad input.
+func (e *ParseError) Is(target error) bool {
+	return target == errBadInput
+}
+
+const (
+	// snippetContext is the number of bytes of input
+	// preceding and following an interval included in
+	// snippets.
+	snippetContext = 20
+
+	// maxSnippetText is the number of bytes of an interval
+	// included in texts and snippets.
+	maxSnippetText = 60
+)
+
+// position is a position in the input of a decoder.
+type position struct {
+	// byte offset
+	offset int64
+	// line number, starting at 1, and the byte offset
+	// at which that line starts
+	line      int
+	lineStart int64
+	// number of intervals before the position
+	n int
+	// end of the input before the position, the last
+	// tailLen bytes of tail
+	tail    [snippetContext]byte
+	tailLen int
+}
+
+// startPosition returns the position at the start
+// of the input.
+func startPosition() position {
+	return position{line: 1}
+}
+
+// advance moves p past the input b.
+func (p *position) advance(b []byte) {
+	if k := bytes.LastIndexByte(b, '\n'); k >= 0 {
+		p.line += bytes.Count(b, []byte{'\n'})
+		p.lineStart = p.offset + int64(k) + 1
+	}
+	p.offset += int64(len(b))
+
+	if len(b) >= snippetContext {
+		p.tailLen = copy(p.tail[:], b[len(b)-snippetContext:])
+		return
+	}
+
+	// keep the last bytes of the tail before b
+	keep := min(p.tailLen, snippetContext-len(b))
+	copy(p.tail[:], p.tail[p.tailLen-keep:p.tailLen])
+	p.tailLen = keep + copy(p.tail[keep:], b)
+}
+
+// lookahead keeps the input following the last token
+// of a scanner, as far as it has been read, for the
+// snippets of parse errors.
+type lookahead struct {
+	buf [snippetContext]byte
+	n   int
+}
+
+// split returns split, keeping the input following
+// each token in l.
+func (l *lookahead) split(split bufio.SplitFunc) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := split(data, atEOF)
+		if token != nil {
+			l.n = copy(l.buf[:], data[advance:])
+		}
+		return advance, token, err
+	}
+}
+
+// bytes returns the input following the last token.
+func (l *lookahead) bytes() []byte {
+	return l.buf[:l.n]
+}
+
+// decoder parses intervals from a reader one at a time,
+// without holding more than a single interval in memory.
+type decoder[T any] struct {
+	scanner *bufio.Scanner
+	d       Domain[T]
+	opts    ParseOptions
+
+	// position of the next token in the input, and the
+	// input following the last one
+	pos   position
+	after lookahead
+}
+
+// newDecoder returns a decoder reading intervals with
+// endpoints in domain d from r, validated according to opts.
+func newDecoder[T any](r io.Reader, d Domain[T], opts ParseOptions) *decoder[T] {
+	return newDecoderAt(r, d, opts, startPosition())
+}
+
+// newDecoderAt returns a decoder as newDecoder does, for
+// input that starts at pos within a larger input, e.g. a
+// chunk of a file. Errors report positions within the
+// larger input.
+func newDecoderAt[T any](r io.Reader, d Domain[T], opts ParseOptions, pos position) *decoder[T] {
+	dec := &decoder[T]{
+		scanner: bufio.NewScanner(r),
+		d:       d,
+		opts:    opts,
+		pos:     pos,
+	}
+
+	// scan inputinterval by interval
+	dec.scanner.Split(dec.after.split(func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		// find *first* closing bracket
+		closingIdx := bytes.IndexAny(data, "])")
+		if closingIdx >= 0 {
+			// return remaining data past the closing bracket
+			buffer := data[:closingIdx+1]
+
+			// advance to the first rune past the closing bracket
+			return closingIdx + 1, buffer, nil
+		}
+
+		// return remaining data if it's the end of the file
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+
+		// continue reading
+		return 0, nil, nil
+	}))
+
+	return dec
+}
+
+// next returns the next valid interval of the input.
+// Intervals dropped according to the parse options are
+// skipped. io.EOF is returned at the end of the input.
+func (dec *decoder[T]) next() (Interval[T], error) {
+	for dec.scanner.Scan() {
+		pos := dec.pos
+		pos.n++
+		dec.pos.advance(dec.scanner.Bytes())
+		dec.pos.n++
+
+		t := dec.scanner.Text()
+		if strings.TrimSpace(t) == "" {
+			// trailing whitespace, e.g. a final newline
+			break
+		}
+
+		i, ok, err := dec.parse(t, pos.n == 1)
+		if err != nil {
+			parseErr := newParseError(pos, t, dec.after.bytes(), err)
+			if !dec.opts.skip(parseErr) {
+				return Interval[T]{}, parseErr
+			}
+			continue
+		}
+		if ok {
+			return i, nil
+		}
+	}
+
+	if err := dec.scanner.Err(); err != nil {
+		return Interval[T]{}, err
+	}
+
+	return Interval[T]{}, io.EOF
+}
+
+// parse parses and validates the interval in token t,
+// the first token of the input if first is true.
+//
+// It returns the interval and true, false if it has to be
+// dropped, or the reason why it is invalid.
+func (dec *decoder[T]) parse(t string, first bool) (Interval[T], bool, error) {
+	textX, textY, bounds, err := dec.split(t, first)
+	if err != nil {
+		return Interval[T]{}, false, err
+	}
+
+	x, err := parseEndpoint(dec.d, textX)
+	if err != nil {
+		return Interval[T]{}, false, err
+	}
+
+	y, err := parseEndpoint(dec.d, textY)
+	if err != nil {
+		return Interval[T]{}, false, err
+	}
+
+	return validate(Interval[T]{X: x, Y: y, Bounds: bounds}, dec.d, dec.opts)
+}
+
+// split splits token t into the text of its endpoints and
+// its bounds: according to the grammar of splitStrict with
+// ParseOptions.Strict, or else only by the first comma,
+// ignoring any brackets around the endpoints.
+func (dec *decoder[T]) split(t string, first bool) (string, string, Bounds, error) {
+	if dec.opts.Strict {
+		return splitStrict(t, first)
+	}
+
+	// commas may separate intervals, as in strict mode
+	if s := strings.TrimLeft(t, space); !first && strings.HasPrefix(s, ",") {
+		t = s[1:]
+	}
+
+	commaIdx := strings.IndexRune(t, ',')
+	if commaIdx < 0 {
+		return "", "", 0, fmt.Errorf("missing comma: %w", errBadInput)
+	}
+
+	var bounds Bounds
+	if strings.HasPrefix(strings.TrimSpace(t[:commaIdx]), "(") {
+		bounds |= LeftOpen
+	}
+	if strings.HasSuffix(strings.TrimSpace(t[commaIdx+1:]), ")") {
+		bounds |= RightOpen
+	}
+
+	return strings.Trim(t[:commaIdx], "[]()"+space), strings.Trim(t[commaIdx+1:], "[]()"+space), bounds, nil
+}
+
+// parseEndpoint converts the trimmed endpoint s with
+// d.Parse. Numbers out of the range of T, reported by the
+// strconv functions with strconv.ErrRange, fail with
+// ErrOutOfRange.
+func parseEndpoint[T any](d Domain[T], s string) (T, error) {
+	v, err := d.Parse(s)
+	switch {
+	case errors.Is(err, strconv.ErrRange):
+		return v, fmt.Errorf("endpoint %q: %w", s, ErrOutOfRange)
+	case err != nil:
+		return v, fmt.Errorf("failed to convert %q to endpoint: %w", s, errBadInput)
+	}
+
+	return v, nil
+}
+
+// newParseError returns a ParseError for token t found at
+// pos, followed by the input after. It points at the
+// interval, not at the whitespace preceding it.
+func newParseError(pos position, t string, after []byte, err error) *ParseError {
+	trimmed := strings.TrimLeftFunc(t, unicode.IsSpace)
+	pos.advance([]byte(t[:len(t)-len(trimmed)]))
+
+	// whitespace after the interval belongs to what follows
+	text := strings.TrimSpace(trimmed)
+	rest := trimmed[len(text):] + string(after)
+	if len(rest) > snippetContext {
+		rest = rest[:snippetContext]
+	}
+	if len(text) > maxSnippetText {
+		text = text[:maxSnippetText] + "..."
+		rest = ""
+	}
+
+	return &ParseError{
+		Interval: pos.n,
+		Offset:   pos.offset,
+		Line:     pos.line,
+		Column:   int(pos.offset-pos.lineStart) + 1,
+		Text:     text,
+		Snippet:  string(pos.tail[:pos.tailLen]) + text + rest,
+		Err:      err,
+	}
+}
+
+// Format converts a list of intervals into
+// a string of white-space separated intervals.
+// Endpoints are converted with d.Format.
+// An empty list results in an empty string.
+func Format[T any](list []Interval[T], d Domain[T]) string {
+	return FormatWith(list, d, FormatOptions{})
+}
+
+// FormatWith converts a list of intervals like Format,
+// in the encoding of opts.
+func FormatWith[T any](list []Interval[T], d Domain[T], opts FormatOptions) string {
+	var b strings.Builder
+	enc := newListEncoder(&b, d, opts, len(list))
+	for _, i := range list {
+		// writing to a strings.Builder does not fail
+		_ = enc.encode(i)
+	}
+	_ = enc.flush()
+
+	return b.String()
+}
+
+// formatInterval converts a single interval into its text
+// representation, using brackets for included endpoints
+// and parentheses for excluded ones.
+func formatInterval[T any](i Interval[T], d Domain[T]) string {
+	opening, closing := '[', ']'
+	if i.leftOpen() {
+		opening = '('
+	}
+	if i.rightOpen() {
+		closing = ')'
+	}
+
+	return fmt.Sprintf("%c%s,%s%c", opening, d.Format(i.X), d.Format(i.Y), closing)
+}
+
+// textEncoder writes intervals in the Text or Lines
+// encoding one at a time, without holding the list
+// in memory.
+type textEncoder[T any] struct {
+	w *bufio.Writer
+	d Domain[T]
+	// separator between intervals
+	sep byte
+	n   int
+}
+
+// encode writes interval i, preceded by the separator
+// unless it is the first one.
+func (enc *textEncoder[T]) encode(i Interval[T]) error {
+	if enc.n > 0 {
+		err := enc.w.WriteByte(enc.sep)
+		if err != nil {
+			return err
+		}
+	}
+	enc.n++
+
+	_, err := enc.w.WriteString(formatInterval(i, enc.d))
+	return err
+}
+
+// flush writes any buffered data to the underlying writer.
+func (enc *textEncoder[T]) flush() error {
+	return enc.w.Flush()
+}
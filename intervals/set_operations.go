@@ -0,0 +1,500 @@
+package intervals
+
+import (
+	"io"
+	"os"
+)
+
+// source yields intervals in ascending order, one at a time.
+type source[T any] interface {
+	// next returns the next interval, or io.EOF after
+	// the last one.
+	next() (Interval[T], error)
+}
+
+// sliceSource is a source reading from a slice in memory.
+type sliceSource[T any] struct {
+	list []Interval[T]
+}
+
+func (s *sliceSource[T]) next() (Interval[T], error) {
+	if len(s.list) == 0 {
+		return Interval[T]{}, io.EOF
+	}
+
+	i := s.list[0]
+	s.list = s.list[1:]
+	return i, nil
+}
+
+// region selects the parts of two lists of intervals a and b
+// that are kept by a set operation.
+type region uint8
+
+const (
+	// onlyA is covered by a, but not by b.
+	onlyA region = 1 << iota
+	// onlyB is covered by b, but not by a.
+	onlyB
+	// both is covered by a and b.
+	both
+)
+
+// Intersect returns the ranges covered by both a and b.
+// E.g.:
+//
+//	a: [1,5] [8,10]
+//	b: [3,9]
+//	Output: [3,5] [8,9]
+//
+// Both lists must be merged, e.g. with Merge. They are
+// walked in a single linear pass; the result is merged.
+func Intersect[T any](a, b []Interval[T], d Domain[T]) []Interval[T] {
+	return combine(a, b, d, both)
+}
+
+// Subtract returns the ranges covered by a, but not by b.
+// E.g.:
+//
+//	a: [1,5] [8,10]
+//	b: [3,9]
+//	Output: [1,3) (9,10]
+//
+// Both lists must be merged, e.g. with Merge. They are
+// walked in a single linear pass; the result is merged.
+func Subtract[T any](a, b []Interval[T], d Domain[T]) []Interval[T] {
+	return combine(a, b, d, onlyA)
+}
+
+// SymmetricDifference returns the ranges covered by
+// either a or b, but not by both.
+// E.g.:
+//
+//	a: [1,5] [8,10]
+//	b: [3,9]
+//	Output: [1,3) (5,8) (9,10]
+//
+// Both lists must be merged, e.g. with Merge. They are
+// walked in a single linear pass; the result is merged.
+func SymmetricDifference[T any](a, b []Interval[T], d Domain[T]) []Interval[T] {
+	return combine(a, b, d, onlyA|onlyB)
+}
+
+// combine returns the parts of the merged lists a and b
+// selected by keep.
+func combine[T any](a, b []Interval[T], d Domain[T], keep region) []Interval[T] {
+	res := make([]Interval[T], 0)
+
+	// neither slice sources nor appending can fail
+	_ = sweep[T](&sliceSource[T]{list: a}, &sliceSource[T]{list: b}, d, keep, func(i Interval[T]) error {
+		res = append(res, i)
+		return nil
+	})
+
+	return res
+}
+
+// Gaps returns the ranges between the intervals of the
+// merged list, i.e. the ranges within the maximum width
+// of the list that are not covered by it.
+// E.g.:
+//
+//	Input: [1,3] [5,7) [9,10]
+//	Output: (3,5) [7,9)
+//
+// Use Complement to look for gaps within other bounds.
+func Gaps[T any](list []Interval[T], d Domain[T]) []Interval[T] {
+	res := make([]Interval[T], 0)
+
+	// neither slice sources nor appending can fail
+	_ = gaps[T](&sliceSource[T]{list: list}, d, func(i Interval[T]) error {
+		res = append(res, i)
+		return nil
+	})
+
+	return res
+}
+
+// Complement returns the ranges within universe that are
+// not covered by the merged list.
+// E.g.:
+//
+//	Input: [1,3] [5,7)
+//	Universe: [0,10]
+//	Output: [0,1) (3,5) [7,10]
+func Complement[T any](list []Interval[T], universe Interval[T], d Domain[T]) []Interval[T] {
+	return combine([]Interval[T]{universe}, list, d, onlyA)
+}
+
+// gaps reads the merged intervals from src and calls emit
+// for each non-empty range between two consecutive ones.
+// Only two intervals are held in memory at a time.
+//
+// Any ocurring errors from src or emit interrupt
+// processing and are returned.
+func gaps[T any](src source[T], d Domain[T], emit func(Interval[T]) error) error {
+	prev, err := src.next()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for {
+		cur, err := src.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// the gap includes the endpoints excluded from
+		// prev and cur
+		var bounds Bounds
+		if !prev.rightOpen() {
+			bounds |= LeftOpen
+		}
+		if !cur.leftOpen() {
+			bounds |= RightOpen
+		}
+
+		gap := Interval[T]{X: prev.Y, Y: cur.X, Bounds: bounds}
+		if d.Compare(gap.X, gap.Y) <= 0 && !gap.isEmpty(d) {
+			err = emit(gap)
+			if err != nil {
+				return err
+			}
+		}
+
+		prev = cur
+	}
+}
+
+// IntersectFiles writes the ranges covered by the intervals
+// in both files at pathA and pathB to a file, as Intersect
+// does for lists in memory. The inputs need not be merged:
+// each is merged first as described in MergeFile.
+//
+// Upon success, the path of the result is returned
+// together with a nil error.
+func IntersectFiles[T any](pathA, pathB string, d Domain[T], opts FileOptions) (string, error) {
+	return combineFiles(pathA, pathB, d, opts, both)
+}
+
+// SubtractFiles writes the ranges covered by the intervals
+// in the file at pathA, but not by those in the file at
+// pathB, to a file, as Subtract does for lists in memory.
+// The inputs need not be merged: each is merged first as
+// described in MergeFile.
+//
+// Upon success, the path of the result is returned
+// together with a nil error.
+func SubtractFiles[T any](pathA, pathB string, d Domain[T], opts FileOptions) (string, error) {
+	return combineFiles(pathA, pathB, d, opts, onlyA)
+}
+
+// SymmetricDifferenceFiles writes the ranges covered by
+// the intervals in either of the files at pathA and pathB,
+// but not by both, to a file, as SymmetricDifference does
+// for lists in memory. The inputs need not be merged: each
+// is merged first as described in MergeFile.
+//
+// Upon success, the path of the result is returned
+// together with a nil error.
+func SymmetricDifferenceFiles[T any](pathA, pathB string, d Domain[T], opts FileOptions) (string, error) {
+	return combineFiles(pathA, pathB, d, opts, onlyA|onlyB)
+}
+
+// GapsFile writes the ranges between the intervals in the
+// file at filePath to a file, as Gaps does for lists in
+// memory. The input need not be merged: it is merged first
+// as described in MergeFile, and the gaps are then computed
+// while streaming the merged intervals.
+//
+// Upon success, the path of the result is returned
+// together with a nil error.
+func GapsFile[T any](filePath string, d Domain[T], opts FileOptions) (string, error) {
+	return gapsFile(filePath, nil, d, opts)
+}
+
+// ComplementFile writes the ranges within universe that are
+// not covered by the intervals in the file at filePath to a
+// file, as Complement does for lists in memory. The input
+// need not be merged: it is merged first as described in
+// MergeFile, and the complement is then computed while
+// streaming the merged intervals.
+//
+// Upon success, the path of the result is returned
+// together with a nil error.
+func ComplementFile[T any](filePath string, universe Interval[T], d Domain[T], opts FileOptions) (string, error) {
+	return gapsFile(filePath, &universe, d, opts)
+}
+
+// gapsFile merges the file at filePath and streams its gaps
+// into the result file: within universe if it is not nil,
+// otherwise between its intervals.
+func gapsFile[T any](filePath string, universe *Interval[T], d Domain[T], opts FileOptions) (string, error) {
+	return processInTempDir(d, opts, estimateSpill(inputSize(filePath)), func(tempDir string) (*os.File, error) {
+		src, err := openMerged(filePath, tempDir, d, opts)
+		if err != nil {
+			return nil, err
+		}
+		defer src.Close()
+
+		return writeTemp(tempDir, d, opts.SpillCompression, func(emit func(Interval[T]) error) error {
+			if universe != nil {
+				return sweep[T](&sliceSource[T]{list: []Interval[T]{*universe}}, src, d, onlyA, emit)
+			}
+			return gaps[T](src, d, emit)
+		})
+	})
+}
+
+// combineFiles merges the files at pathA and pathB and
+// streams the parts selected by keep into the result file,
+// without holding either list in memory.
+func combineFiles[T any](pathA, pathB string, d Domain[T], opts FileOptions, keep region) (string, error) {
+	return processInTempDir(d, opts, estimateSpill(inputSize(pathA), inputSize(pathB)), func(tempDir string) (*os.File, error) {
+		a, err := openMerged(pathA, tempDir, d, opts)
+		if err != nil {
+			return nil, err
+		}
+		defer a.Close()
+
+		b, err := openMerged(pathB, tempDir, d, opts)
+		if err != nil {
+			return nil, err
+		}
+		defer b.Close()
+
+		return writeTemp(tempDir, d, opts.SpillCompression, func(emit func(Interval[T]) error) error {
+			return sweep[T](a, b, d, keep, emit)
+		})
+	})
+}
+
+// mergedFile is a source streaming the intervals of a
+// merged intermediate file.
+type mergedFile[T any] struct {
+	source[T]
+	r    io.ReadCloser
+	file *os.File
+}
+
+// Close closes the underlying reader and file.
+func (m *mergedFile[T]) Close() error {
+	m.r.Close()
+	return m.file.Close()
+}
+
+// openMerged merges the file at filePath into tempDir as
+// described in MergeFile and returns a source streaming
+// the merged intervals. It is the responsibility of the
+// caller to close it.
+func openMerged[T any](filePath string, tempDir string, d Domain[T], opts FileOptions) (*mergedFile[T], error) {
+	f, err := mergeFile(filePath, tempDir, d, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := openRun(f, opts.SpillCompression)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	// merged intermediate files are always valid
+	return &mergedFile[T]{source: newBinaryDecoder(r, d, ParseOptions{}), r: r, file: f}, nil
+}
+
+// writeTemp creates a file in tempDir and writes the
+// intervals passed to emit by produce into it, compressed
+// with c.
+//
+// Upon success, the file is returned open together with
+// a nil error. Errors from produce are returned.
+func writeTemp[T any](tempDir string, d Domain[T], c Compression, produce func(emit func(Interval[T]) error) error) (*os.File, error) {
+	f, err := os.CreateTemp(tempDir, "*")
+	if err != nil {
+		return nil, err
+	}
+
+	// runs are written as Binary, with the number of
+	// intervals filled in once known unless compressed
+	err = compressed(f, c, func(w io.Writer) error {
+		enc := newBinaryEncoder(w, d, unknownCount)
+		err := produce(enc.encode)
+		if err == nil {
+			err = enc.flush()
+		}
+		if err == nil && c == NoCompression {
+			err = writeCount(f, enc.n)
+		}
+		return err
+	})
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// sweep walks the merged intervals of a and b in a single
+// pass, cutting them at each other's endpoints, and calls
+// emit for the parts in the regions selected by keep.
+// Emitted intervals are in ascending order and merged.
+//
+// Any ocurring errors from a, b or emit interrupt the
+// sweep and are returned.
+func sweep[T any](a, b source[T], d Domain[T], keep region, emit func(Interval[T]) error) error {
+	out := coalescer[T]{d: d, emit: emit}
+	put := func(r region, i Interval[T]) error {
+		if keep&r == 0 || i.isEmpty(d) {
+			return nil
+		}
+		return out.add(i)
+	}
+
+	x, errA := a.next()
+	if errA != nil && errA != io.EOF {
+		return errA
+	}
+	y, errB := b.next()
+	if errB != nil && errB != io.EOF {
+		return errB
+	}
+
+	for errA == nil || errB == nil {
+		var err error
+		switch {
+		case errB == io.EOF || errA == nil && x.before(y, d):
+			err = put(onlyA, x)
+			x, errA = a.next()
+
+		case errA == io.EOF || y.before(x, d):
+			err = put(onlyB, y)
+			y, errB = b.next()
+
+		default:
+			// x and y overlap: cut off the part before
+			// the later one begins
+			if c := compareLeft(x, y, d.Compare); c < 0 {
+				err = put(onlyA, x.head(y))
+				x = Interval[T]{X: y.X, Y: x.Y, Bounds: y.Bounds&LeftOpen | x.Bounds&RightOpen}
+			} else if c > 0 {
+				err = put(onlyB, y.head(x))
+				y = Interval[T]{X: x.X, Y: y.Y, Bounds: x.Bounds&LeftOpen | y.Bounds&RightOpen}
+			}
+			if err != nil {
+				return err
+			}
+
+			// x and y begin together now: the common part
+			// lasts until the earlier one ends
+			c := compareRight(x, y, d.Compare)
+			if c <= 0 {
+				err = put(both, x)
+			} else {
+				err = put(both, y)
+			}
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case c < 0:
+				y = y.tail(x)
+				x, errA = a.next()
+			case c > 0:
+				x = x.tail(y)
+				y, errB = b.next()
+			default:
+				x, errA = a.next()
+				y, errB = b.next()
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+		if errA != nil && errA != io.EOF {
+			return errA
+		}
+		if errB != nil && errB != io.EOF {
+			return errB
+		}
+	}
+
+	return out.flush()
+}
+
+// before reports whether interval a ends before b
+// begins, without any value in common.
+func (a Interval[T]) before(b Interval[T], d Domain[T]) bool {
+	c := d.Compare(a.Y, b.X)
+	return c < 0 || c == 0 && (a.rightOpen() || b.leftOpen())
+}
+
+// head returns the part of interval a before b begins.
+func (a Interval[T]) head(b Interval[T]) Interval[T] {
+	bounds := a.Bounds & LeftOpen
+	if !b.leftOpen() {
+		bounds |= RightOpen
+	}
+
+	return Interval[T]{X: a.X, Y: b.X, Bounds: bounds}
+}
+
+// tail returns the part of interval a after b ends.
+func (a Interval[T]) tail(b Interval[T]) Interval[T] {
+	bounds := a.Bounds & RightOpen
+	if !b.rightOpen() {
+		bounds |= LeftOpen
+	}
+
+	return Interval[T]{X: b.Y, Y: a.Y, Bounds: bounds}
+}
+
+// coalescer merges consecutive intervals handed to it in
+// ascending order before passing them on to emit, e.g.
+// [1,3) and [3,5] into [1,5], connecting them according
+// to opts.
+type coalescer[T any] struct {
+	d       Domain[T]
+	opts    MergeOptions
+	emit    func(Interval[T]) error
+	pending Interval[T]
+	ok      bool
+}
+
+// add hands interval i to the coalescer.
+func (c *coalescer[T]) add(i Interval[T]) error {
+	if c.ok {
+		merged, ok := c.pending.mergeIfSortedAndOverlap(i, c.d, c.opts)
+		if ok {
+			c.pending = merged
+			return nil
+		}
+
+		err := c.emit(c.pending)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.pending, c.ok = i, true
+	return nil
+}
+
+// flush passes the last pending interval on to emit.
+func (c *coalescer[T]) flush() error {
+	if !c.ok {
+		return nil
+	}
+
+	c.ok = false
+	return c.emit(c.pending)
+}
@@ -0,0 +1,192 @@
+package intervals
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetOperations(t *testing.T) {
+	testcases := []struct {
+		a, b                string
+		intersect           string
+		subtract            string
+		symmetricDifference string
+	}{
+		// Testcase from the documentation
+		{
+			a:                   "[1,5] [8,10]",
+			b:                   "[3,9]",
+			intersect:           "[3,5] [8,9]",
+			subtract:            "[1,3) (9,10]",
+			symmetricDifference: "[1,3) (5,8) (9,10]",
+		},
+		// Empty operands
+		{
+			a:                   "",
+			b:                   "[1,2]",
+			intersect:           "",
+			subtract:            "",
+			symmetricDifference: "[1,2]",
+		},
+		// Equal lists
+		{
+			a:                   "[1,2] [4,5)",
+			b:                   "[1,2] [4,5)",
+			intersect:           "[1,2] [4,5)",
+			subtract:            "",
+			symmetricDifference: "",
+		},
+		// Disjoint lists
+		{
+			a:                   "[1,2] [5,6]",
+			b:                   "[3,4] [7,8]",
+			intersect:           "",
+			subtract:            "[1,2] [5,6]",
+			symmetricDifference: "[1,2] [3,4] [5,6] [7,8]",
+		},
+		// Touching at an endpoint included in a only
+		{
+			a:                   "[1,3]",
+			b:                   "(3,5]",
+			intersect:           "",
+			subtract:            "[1,3]",
+			symmetricDifference: "[1,5]",
+		},
+		// Touching at an endpoint included in both
+		{
+			a:                   "[1,3]",
+			b:                   "[3,5]",
+			intersect:           "[3,3]",
+			subtract:            "[1,3)",
+			symmetricDifference: "[1,3) (3,5]",
+		},
+		// One interval of b cuts several intervals of a
+		{
+			a:                   "[1,3] [4,6] [7,9]",
+			b:                   "(2,8)",
+			intersect:           "(2,3] [4,6] [7,8)",
+			subtract:            "[1,2] [8,9]",
+			symmetricDifference: "[1,2] (3,4) (6,7) [8,9]",
+		},
+		// Holes punched into a single interval
+		{
+			a:                   "[0,10]",
+			b:                   "[1,2] (4,5) [10,10]",
+			intersect:           "[1,2] (4,5) [10,10]",
+			subtract:            "[0,1) (2,4] [5,10)",
+			symmetricDifference: "[0,1) (2,4] [5,10)",
+		},
+	}
+
+	for _, test := range testcases {
+		a, err := Parse(strings.NewReader(test.a), Float64)
+		assert.NoError(t, err)
+		b, err := Parse(strings.NewReader(test.b), Float64)
+		assert.NoError(t, err)
+
+		assert.Equal(t, test.intersect, Format(Intersect(a, b, Float64), Float64), fmt.Sprintf("testcase: %+v", test))
+		assert.Equal(t, test.subtract, Format(Subtract(a, b, Float64), Float64), fmt.Sprintf("testcase: %+v", test))
+		assert.Equal(t, test.symmetricDifference, Format(SymmetricDifference(a, b, Float64), Float64), fmt.Sprintf("testcase: %+v", test))
+	}
+
+	// parts without any integer are dropped in a discrete domain
+	a := []Interval[int]{{X: 1, Y: 3}, {X: 4, Y: 6}}
+	b := []Interval[int]{{X: 2, Y: 5, Bounds: Open}}
+	assert.Equal(t, "[1,2] [5,6]", Format(Subtract(a, b, Int), Int))
+	assert.Equal(t, "(2,3] [4,5)", Format(Intersect(a, b, Int), Int))
+	assert.Equal(t, "[1,2] [5,6]", Format(SymmetricDifference(a, b, Int), Int))
+}
+
+func TestSetOperationsFiles(t *testing.T) {
+	dir := t.TempDir()
+	pathA := filepath.Join(dir, "a.txt")
+	pathB := filepath.Join(dir, "b.txt")
+	assert.NoError(t, os.WriteFile(pathA, []byte("[8,10] [1,3] [2,5]"), 0o644))
+	assert.NoError(t, os.WriteFile(pathB, []byte("[3,9]\n"), 0o644))
+
+	testcases := []struct {
+		combine  func(pathA, pathB string, d Domain[int], opts FileOptions) (string, error)
+		expected string
+	}{
+		{combine: IntersectFiles[int], expected: "[3,5] [8,9]\n"},
+		{combine: SubtractFiles[int], expected: "[1,3) (9,10]\n"},
+		{combine: SymmetricDifferenceFiles[int], expected: "[1,3) (5,8) (9,10]\n"},
+	}
+
+	for _, test := range testcases {
+		resFile, err := test.combine(pathA, pathB, Int, FileOptions{ChunkSize: 5})
+		assert.NoError(t, err)
+
+		b, err := os.ReadFile(resFile)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, string(b))
+	}
+
+	t.Cleanup(func() {
+		assert.NoError(t, os.Remove(resultFileName))
+	})
+}
+
+func TestGaps(t *testing.T) {
+	testcases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "", expected: ""},
+		{input: "[1,3]", expected: ""},
+		{input: "[1,3] [5,7) [9,10]", expected: "(3,5) [7,9)"},
+		{input: "[1,3) (3,5]", expected: "[3,3]"},
+		{input: "[1,3) [3,5]", expected: ""},
+	}
+
+	for _, test := range testcases {
+		list, err := Parse(strings.NewReader(test.input), Float64)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, Format(Gaps(Merge(list, Float64), Float64), Float64), fmt.Sprintf("testcase: %+v", test))
+	}
+}
+
+func TestComplement(t *testing.T) {
+	testcases := []struct {
+		input    string
+		universe Interval[float64]
+		expected string
+	}{
+		{input: "", universe: Interval[float64]{X: 0, Y: 10}, expected: "[0,10]"},
+		{input: "[1,3] [5,7)", universe: Interval[float64]{X: 0, Y: 10}, expected: "[0,1) (3,5) [7,10]"},
+		{input: "[1,3] [5,7)", universe: Interval[float64]{X: 2, Y: 6, Bounds: Open}, expected: "(3,5)"},
+		{input: "[-5,0] [9,20]", universe: Interval[float64]{X: 0, Y: 10}, expected: "(0,9)"},
+	}
+
+	for _, test := range testcases {
+		list, err := Parse(strings.NewReader(test.input), Float64)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, Format(Complement(Merge(list, Float64), test.universe, Float64), Float64), fmt.Sprintf("testcase: %+v", test))
+	}
+}
+
+func TestGapsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("[9,10] [1,3] [5,7) [2,4]"), 0o644))
+
+	resFile, err := GapsFile(path, Float64, FileOptions{ChunkSize: 5})
+	assert.NoError(t, err)
+	b, err := os.ReadFile(resFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "(4,5) [7,9)\n", string(b))
+
+	resFile, err = ComplementFile(path, Interval[float64]{X: 0, Y: 12}, Float64, FileOptions{ChunkSize: 5})
+	assert.NoError(t, err)
+	b, err = os.ReadFile(resFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "[0,1) (4,5) [7,9) (10,12]\n", string(b))
+
+	t.Cleanup(func() {
+		assert.NoError(t, os.Remove(resultFileName))
+	})
+}
@@ -0,0 +1,218 @@
+package intervals
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+var (
+	errNotDiscrete = errors.New("adjacency merging requires a discrete domain")
+	errNoAdd       = errors.New("adjacency and gap merging require Domain.Add")
+	errNegativeGap = errors.New("gap must not be negative")
+)
+
+// MergeOptions configures which intervals MergeWith
+// considers connected. The zero value merges only
+// intervals that overlap or share an included endpoint.
+type MergeOptions struct {
+	// Adjacent merges intervals that touch at consecutive
+	// values of a discrete domain, e.g. [1,2] and [3,4]
+	// into [1,4]. Open endpoints are taken into account:
+	// [1,3) and [3,4] are adjacent, [1,3) and [4,5] are not.
+	Adjacent bool
+
+	// Gap merges intervals that are at most Gap units of
+	// the domain apart, e.g. [1,2] and [5,6] with a Gap
+	// of 3. For the Time domain, a unit is a nanosecond.
+	// Gap is added to the adjacency of consecutive values
+	// if Adjacent is set as well.
+	Gap int64
+}
+
+// Merge merges overlapping intervals from input.
+// Non-overlapping intervals are included in the result.
+// E.g.:
+//
+//	Input: [25,30] [2,19] [14, 23] [4,8]
+//	Output: [2,23] [25,30]
+//
+// Endpoints are ordered with d.Compare.
+//
+// Merge operates in-place to efficiently manage memory.
+// The underlying array will be modified.
+func Merge[T any](intervals []Interval[T], d Domain[T]) []Interval[T] {
+	// the zero value of MergeOptions is always valid
+	res, _ := MergeWith(intervals, d, MergeOptions{})
+	return res
+}
+
+// MergeWith merges intervals like Merge, additionally
+// merging intervals that opts considers connected.
+// E.g., with opts.Adjacent:
+//
+//	Input: [1,2] [3,4] [6,7]
+//	Output: [1,4] [6,7]
+//
+// An error is returned, together with an empty slice,
+// if opts cannot be applied to domain d.
+func MergeWith[T any](intervals []Interval[T], d Domain[T], opts MergeOptions) ([]Interval[T], error) {
+	err := checkMergeOptions(opts, d)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(intervals) < 2 {
+		return intervals, nil
+	}
+
+	slices.SortFunc(intervals, func(a, b Interval[T]) int {
+		return compareLeft(a, b, d.Compare)
+	})
+	i := 0
+	for i < len(intervals)-1 {
+		merged, ok := intervals[i].mergeIfSortedAndOverlap(intervals[i+1], d, opts)
+		if ok {
+			intervals[i] = merged
+			// remove i+1 from the list
+			intervals = append(intervals[:i+1], intervals[i+2:]...)
+			intervals = intervals[:len(intervals):len(intervals)]
+		} else {
+			i++
+		}
+	}
+
+	return intervals, nil
+}
+
+// checkMergeOptions returns an error if opts cannot be
+// applied to intervals with endpoints in domain d.
+func checkMergeOptions[T any](opts MergeOptions, d Domain[T]) error {
+	if opts.Gap < 0 {
+		return errNegativeGap
+	}
+
+	if (opts.Adjacent || opts.Gap > 0) && d.Add == nil {
+		return errNoAdd
+	}
+
+	if opts.Adjacent && !d.Discrete {
+		return errNotDiscrete
+	}
+
+	return nil
+}
+
+// mergeIfSortedAndOverlap merges intervals a y b iff:
+// they are *sorted in ascending order by left endpoint*
+// **and** *overlap*.
+//
+// That is:
+//
+//   - interval a begins *before or at the same left
+//     endpoint* as b .
+//
+//   - interval a ends *either at or after* b's left endpoint,
+//     or close enough to it according to opts - see
+//     MergeOptions.
+//
+//     E.g.:
+//
+//     a: [x, ...->|
+//     b: |<- [x, ...
+//
+// Bounds are taken into account: if a ends exactly where
+// b begins, they are merged unless the shared endpoint is
+// excluded from both, e.g. [1,2) and [2,3] merge into [1,3],
+// but [1,2) and (2,3] do not. On equal left endpoints, an
+// included endpoint counts as beginning before an excluded one.
+//
+// In that case, the function returns the merged intervals
+// and true.
+//
+// Note that, under these circumstances, overlapping
+// intervals can only fall into one of the following cases:
+//
+// 1) Either interval a ends before interval b:
+//
+//	a: ...,y]->)
+//	b:    ...,y]
+//
+// resulting in a merged interval of [a.x, b.y], with b's
+// right bound, or
+//
+// 2) interval a ends either at the same value of interval
+// b or after it:
+//
+//	a: ...,y]-->
+//	b: ...,y]
+//
+// resulting in a merged interval of [a.x, a.y], with a's
+// right bound.
+//
+// For any other case, this function returns an empty
+// interval and false.
+//
+// Use the second return value to check if the input
+// intervals did indeed overlap.
+func (a Interval[T]) mergeIfSortedAndOverlap(b Interval[T], d Domain[T], opts MergeOptions) (Interval[T], bool) {
+	cmp := d.Compare
+	if compareLeft(a, b, cmp) <= 0 && a.connects(b, d, opts) {
+		if compareRight(a, b, cmp) < 0 {
+			return Interval[T]{X: a.X, Y: b.Y, Bounds: a.Bounds&LeftOpen | b.Bounds&RightOpen}, true
+		}
+		return Interval[T]{X: a.X, Y: a.Y, Bounds: a.Bounds}, true
+	}
+
+	return Interval[T]{}, false
+}
+
+// connects reports whether b begins before a ends, or
+// exactly where a ends with the shared endpoint included
+// in at least one of them.
+//
+// With opts.Adjacent, both intervals are first reduced to
+// the closed intervals of a discrete domain, and b may begin
+// at the value following a's end. With opts.Gap, b may begin
+// up to opts.Gap units past a's end.
+func (a Interval[T]) connects(b Interval[T], d Domain[T], opts MergeOptions) bool {
+	c := d.Compare(b.X, a.Y)
+	if c < 0 || c == 0 && !(a.rightOpen() && b.leftOpen()) {
+		return true
+	}
+
+	if opts.Adjacent {
+		end := a.Y
+		if a.rightOpen() {
+			end = d.Add(end, -1)
+		}
+		start := b.X
+		if b.leftOpen() {
+			start = d.Add(start, 1)
+		}
+
+		return d.Compare(start, d.Add(d.Add(end, 1), opts.Gap)) <= 0
+	}
+
+	if opts.Gap > 0 {
+		return d.Compare(b.X, d.Add(a.Y, opts.Gap)) <= 0
+	}
+
+	return false
+}
+
+// MergeString parses a string into a slice
+// of intervals with endpoints in domain d and merges it.
+// Upon success, it returns the merged list and
+// a nil error.
+// Any ocurring parsing errors will be returned
+// with an empty slice.
+func MergeString[T any](s string, d Domain[T]) ([]Interval[T], error) {
+	list, err := Parse(strings.NewReader(s), d)
+	if err != nil {
+		return nil, err
+	}
+
+	return Merge(list, d), nil
+}
@@ -0,0 +1,88 @@
+package intervals
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// space are the whitespace characters allowed around
+// intervals and their endpoints.
+const space = " \t\r\n"
+
+// splitStrict splits token t, as cut by the decoder after
+// a closing bracket, into the text of its endpoints and its
+// bounds. It accepts the grammar
+//
+//	list      = ws [ interval { separator interval } ] ws
+//	separator = ws [ "," ] ws
+//	interval  = ( "[" | "(" ) ws endpoint ws "," ws endpoint ws ( "]" | ")" )
+//	endpoint  = any characters but brackets, commas and ws, e.g. a sign and digits
+//	ws        = { " " | "\t" | "\r" | "\n" }
+//
+// first is true for the first token of the input, which
+// must not begin with a separating comma.
+func splitStrict(t string, first bool) (x, y string, bounds Bounds, err error) {
+	s := strings.TrimLeft(t, space)
+	if !first && strings.HasPrefix(s, ",") {
+		s = strings.TrimLeft(s[1:], space)
+	}
+
+	switch {
+	case strings.HasPrefix(s, "["):
+	case strings.HasPrefix(s, "("):
+		bounds |= LeftOpen
+	default:
+		return "", "", 0, unexpected(s, "opening bracket")
+	}
+	s = strings.TrimLeft(s[1:], space)
+
+	x, s = endpointText(s)
+	if x == "" {
+		return "", "", 0, unexpected(s, "endpoint")
+	}
+	s = strings.TrimLeft(s, space)
+
+	if !strings.HasPrefix(s, ",") {
+		return "", "", 0, unexpected(s, "comma")
+	}
+	s = strings.TrimLeft(s[1:], space)
+
+	y, s = endpointText(s)
+	if y == "" {
+		return "", "", 0, unexpected(s, "endpoint")
+	}
+	s = strings.TrimLeft(s, space)
+
+	// the token ends at the first closing bracket
+	switch s {
+	case "]":
+	case ")":
+		bounds |= RightOpen
+	default:
+		return "", "", 0, unexpected(s, "closing bracket")
+	}
+
+	return x, y, bounds, nil
+}
+
+// endpointText returns the endpoint at the start of s
+// and the rest of s.
+func endpointText(s string) (string, string) {
+	end := strings.IndexAny(s, "[](),"+space)
+	if end < 0 {
+		end = len(s)
+	}
+	return s[:end], s[end:]
+}
+
+// unexpected returns the error for finding the rest s of
+// a token where the grammar expects the element want.
+func unexpected(s string, want string) error {
+	if s == "" {
+		return fmt.Errorf("missing %s at end of input: %w", want, errBadInput)
+	}
+
+	r, _ := utf8.DecodeRuneInString(s)
+	return fmt.Errorf("unexpected %q, expected %s: %w", r, want, errBadInput)
+}
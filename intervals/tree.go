@@ -0,0 +1,114 @@
+package intervals
+
+import (
+	"golang.org/x/exp/slices"
+)
+
+// Tree answers point and range queries on a list of
+// possibly overlapping intervals, e.g. before merging.
+// Queries return the original intervals.
+//
+// Tree is a static augmented interval tree: a balanced
+// binary search tree over the intervals ordered by left
+// endpoint, where each node also knows the interval ending
+// last within its subtree. Subtrees ending before a query
+// are skipped, so a query takes O(log n) plus the number
+// of intervals returned.
+//
+// A Tree is immutable and safe for concurrent use.
+// Use Index for merged lists.
+type Tree[T any] struct {
+	// intervals sorted by left endpoint; the tree over
+	// list[lo:hi] has its root at the middle.
+	list []Interval[T]
+	// maxEnd[k] is the interval ending last in the
+	// subtree rooted at list[k].
+	maxEnd []Interval[T]
+	d      Domain[T]
+}
+
+// NewTree returns a Tree of the intervals in list. The list
+// is copied: later changes to it do not affect the Tree.
+func NewTree[T any](list []Interval[T], d Domain[T]) *Tree[T] {
+	t := &Tree[T]{
+		list:   make([]Interval[T], len(list)),
+		maxEnd: make([]Interval[T], len(list)),
+		d:      d,
+	}
+	copy(t.list, list)
+
+	slices.SortFunc(t.list, func(a, b Interval[T]) int {
+		return compareLeft(a, b, d.Compare)
+	})
+	t.build(0, len(t.list))
+
+	return t
+}
+
+// build computes maxEnd for the subtree over list[lo:hi]
+// and returns the index of the interval ending last in
+// it, or -1 if it is empty.
+func (t *Tree[T]) build(lo, hi int) int {
+	if lo >= hi {
+		return -1
+	}
+
+	mid := int(uint(lo+hi) >> 1)
+	last := mid
+	for _, k := range []int{t.build(lo, mid), t.build(mid+1, hi)} {
+		if k >= 0 && compareRight(t.list[k], t.list[last], t.d.Compare) > 0 {
+			last = k
+		}
+	}
+	t.maxEnd[mid] = t.list[last]
+
+	return last
+}
+
+// Len returns the number of intervals in the Tree.
+func (t *Tree[T]) Len() int {
+	return len(t.list)
+}
+
+// Stab returns the intervals containing point p,
+// ordered by left endpoint.
+func (t *Tree[T]) Stab(p T) []Interval[T] {
+	return t.Overlapping(Interval[T]{X: p, Y: p})
+}
+
+// Overlapping returns the intervals having at least one
+// value in common with q, ordered by left endpoint.
+func (t *Tree[T]) Overlapping(q Interval[T]) []Interval[T] {
+	res := make([]Interval[T], 0)
+	t.query(0, len(t.list), q, &res)
+
+	return res
+}
+
+// query appends the intervals of the subtree over
+// list[lo:hi] overlapping q to res, in order.
+func (t *Tree[T]) query(lo, hi int, q Interval[T], res *[]Interval[T]) {
+	if lo >= hi {
+		return
+	}
+
+	mid := int(uint(lo+hi) >> 1)
+	if t.maxEnd[mid].before(q, t.d) {
+		// the whole subtree ends before q begins
+		return
+	}
+
+	t.query(lo, mid, q, res)
+
+	if q.before(t.list[mid], t.d) {
+		// q ends before this interval and all intervals
+		// to its right begin
+		return
+	}
+
+	if t.list[mid].overlaps(q, t.d) {
+		*res = append(*res, t.list[mid])
+	}
+
+	t.query(mid+1, hi, q, res)
+}
@@ -0,0 +1,62 @@
+package intervals
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTree(t *testing.T) {
+	list, err := Parse(strings.NewReader("[25,30] [2,19] [14, 23] [4,8] (8,10) [30,30]"), Int)
+	assert.NoError(t, err)
+	tree := NewTree(list, Int)
+	assert.Equal(t, 6, tree.Len())
+
+	testcases := []struct {
+		query    Interval[int]
+		expected string
+	}{
+		{query: Interval[int]{X: 0, Y: 1}, expected: ""},
+		{query: Interval[int]{X: 0, Y: 100}, expected: "[2,19] [4,8] (8,10) [14,23] [25,30] [30,30]"},
+		{query: Interval[int]{X: 8, Y: 8}, expected: "[2,19] [4,8]"},
+		{query: Interval[int]{X: 20, Y: 25, Bounds: RightOpen}, expected: "[14,23]"},
+		{query: Interval[int]{X: 30, Y: 31}, expected: "[25,30] [30,30]"},
+		{query: Interval[int]{X: 31, Y: 40}, expected: ""},
+	}
+
+	for _, test := range testcases {
+		assert.Equal(t, test.expected, Format(tree.Overlapping(test.query), Int), fmt.Sprintf("testcase: %+v", test))
+	}
+
+	assert.Equal(t, "[2,19] [14,23]", Format(tree.Stab(15), Int))
+}
+
+func TestTreeMatchesLinearScan(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	random := func() Interval[int] {
+		x := r.Intn(1000)
+		return Interval[int]{X: x, Y: x + r.Intn(50), Bounds: Bounds(r.Intn(4))}
+	}
+
+	list := make([]Interval[int], 500)
+	for k := range list {
+		list[k] = random()
+	}
+	tree := NewTree(list, Int)
+
+	for n := 0; n < 1000; n++ {
+		q := random()
+
+		expected := make([]Interval[int], 0)
+		for _, i := range tree.list {
+			if i.overlaps(q, Int) {
+				expected = append(expected, i)
+			}
+		}
+
+		assert.Equal(t, expected, tree.Overlapping(q), fmt.Sprintf("query: %+v", q))
+	}
+}
@@ -0,0 +1,140 @@
+package intervals
+
+import (
+	"errors"
+)
+
+var (
+	errReversed = errors.New("left endpoint is greater than right endpoint")
+	errEmpty    = errors.New("interval is empty")
+)
+
+// ReversedPolicy decides how intervals whose left endpoint
+// is greater than their right endpoint, e.g. [5,1], are
+// handled while parsing.
+type ReversedPolicy uint8
+
+const (
+	// RejectReversed fails parsing with an error pointing
+	// at the reversed interval.
+	RejectReversed ReversedPolicy = iota
+	// SwapReversed swaps the endpoints, together with
+	// their bounds: [5,1) becomes (1,5].
+	SwapReversed
+	// DropReversed removes the interval from the result.
+	DropReversed
+)
+
+// EmptyPolicy decides how empty intervals are handled while
+// parsing. An interval is empty if it contains no value,
+// e.g. (3,3) or [3,3), or (3,4) in a discrete domain.
+type EmptyPolicy uint8
+
+const (
+	// KeepEmpty keeps empty intervals in the result.
+	KeepEmpty EmptyPolicy = iota
+	// DropEmpty removes empty intervals from the result.
+	DropEmpty
+	// RejectEmpty fails parsing with an error pointing
+	// at the empty interval.
+	RejectEmpty
+)
+
+// ErrorPolicy decides how intervals that cannot be parsed
+// or are rejected, i.e. that result in a *ParseError, are
+// handled while parsing.
+type ErrorPolicy uint8
+
+const (
+	// FailOnError fails parsing with the error of the
+	// first invalid interval.
+	FailOnError ErrorPolicy = iota
+	// SkipOnError removes invalid intervals from the result,
+	// passes their error to ParseOptions.Reject and goes on.
+	SkipOnError
+)
+
+// ParseOptions configures ParseWith. The zero value
+// rejects reversed intervals, keeps empty ones and fails
+// on the first invalid interval.
+type ParseOptions struct {
+	Reversed ReversedPolicy
+	Empty    EmptyPolicy
+	OnError  ErrorPolicy
+
+	// Encoding is the encoding of the input, Text by
+	// default. JSONArrays and JSONObjects both read a
+	// JSON array of intervals in either encoding.
+	Encoding Encoding
+
+	// CSV configures the columns of CSV input.
+	CSV CSVOptions
+
+	// Strict rejects input outside the interval grammar,
+	// e.g. missing or doubled brackets and text between
+	// intervals, which is ignored by default: intervals
+	// are written as [x,y], (x,y), [x,y) or (x,y] and may
+	// be separated by whitespace and a single comma.
+	// JSON input is always checked strictly.
+	Strict bool
+
+	// Reject is called with the error of each interval
+	// skipped with SkipOnError, if it is not nil. In file
+	// mode, calls are not concurrent, but not necessarily
+	// in the order of the input either.
+	Reject func(err *ParseError)
+}
+
+// skip reports whether the interval of err is skipped
+// according to opts, passing err to opts.Reject if so.
+func (opts ParseOptions) skip(err *ParseError) bool {
+	if opts.OnError != SkipOnError {
+		return false
+	}
+
+	if opts.Reject != nil {
+		opts.Reject(err)
+	}
+	return true
+}
+
+// validate applies opts to interval i.
+//
+// It returns the interval to use instead of i and true,
+// false if i has to be dropped, or an error if i has
+// to be rejected.
+func validate[T any](i Interval[T], d Domain[T], opts ParseOptions) (Interval[T], bool, error) {
+	if d.Compare(i.X, i.Y) > 0 {
+		switch opts.Reversed {
+		case SwapReversed:
+			i = Interval[T]{X: i.Y, Y: i.X, Bounds: i.Bounds&LeftOpen<<1 | i.Bounds&RightOpen>>1}
+		case DropReversed:
+			return Interval[T]{}, false, nil
+		default:
+			return Interval[T]{}, false, errReversed
+		}
+	}
+
+	if i.isEmpty(d) {
+		switch opts.Empty {
+		case DropEmpty:
+			return Interval[T]{}, false, nil
+		case RejectEmpty:
+			return Interval[T]{}, false, errEmpty
+		}
+	}
+
+	return i, true, nil
+}
+
+// isEmpty reports whether the interval contains no value
+// of domain d. The interval must not be reversed.
+func (i Interval[T]) isEmpty(d Domain[T]) bool {
+	c := d.Compare(i.X, i.Y)
+	if c == 0 {
+		return i.Bounds != Closed
+	}
+
+	// (x,x+1) is empty in a discrete domain
+	return c < 0 && i.Bounds == Open && d.Discrete && d.Compare(d.Add(i.X, 1), i.Y) == 0
+}
@@ -0,0 +1,210 @@
+package intervals
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWith(t *testing.T) {
+	testcases := []struct {
+		input    string
+		opts     ParseOptions
+		expected []Interval[int]
+		error    error
+	}{
+		// Reversed intervals are rejected by default
+		{
+			input: "[1,2] [5,1]",
+			error: errReversed,
+		},
+		{
+			input: "[1,2] [5,1)",
+			opts:  ParseOptions{Reversed: SwapReversed},
+			expected: []Interval[int]{
+				{X: 1, Y: 2},
+				{X: 1, Y: 5, Bounds: LeftOpen},
+			},
+		},
+		{
+			input: "[1,2] [5,1]",
+			opts:  ParseOptions{Reversed: DropReversed},
+			expected: []Interval[int]{
+				{X: 1, Y: 2},
+			},
+		},
+		// Empty intervals are kept by default
+		{
+			input: "(3,3) [3,3)",
+			expected: []Interval[int]{
+				{X: 3, Y: 3, Bounds: Open},
+				{X: 3, Y: 3, Bounds: RightOpen},
+			},
+		},
+		{
+			input: "(3,3) [3,3] (3,4) (3,5)",
+			opts:  ParseOptions{Empty: DropEmpty},
+			expected: []Interval[int]{
+				{X: 3, Y: 3},
+				{X: 3, Y: 5, Bounds: Open},
+			},
+		},
+		{
+			input: "[1,2] (3,3]",
+			opts:  ParseOptions{Empty: RejectEmpty},
+			error: errEmpty,
+		},
+		// Swapped intervals are validated for emptiness as well
+		{
+			input:    "(4,3)",
+			opts:     ParseOptions{Reversed: SwapReversed, Empty: DropEmpty},
+			expected: []Interval[int]{},
+		},
+	}
+
+	for _, test := range testcases {
+		res, err := ParseWith(strings.NewReader(test.input), Int, test.opts)
+		assert.Equal(t, test.expected, res, fmt.Sprintf("testcase: %v", test))
+		assert.ErrorIs(t, err, test.error, fmt.Sprintf("testcase: %v", test))
+	}
+}
+
+func TestParseWithErrorPosition(t *testing.T) {
+	_, err := Parse(strings.NewReader("[1,2]\n  [5,1]"), Int)
+	assert.EqualError(t, err, `invalid interval 2 "[5,1]" at line 2, column 3 (offset 8): left endpoint is greater than right endpoint`)
+}
+
+func TestParseError(t *testing.T) {
+	testcases := []struct {
+		input    string
+		expected ParseError
+		reason   error
+	}{
+		{
+			input:    "[1,x]",
+			expected: ParseError{Interval: 1, Offset: 0, Line: 1, Column: 1, Text: "[1,x]", Snippet: "[1,x]"},
+		},
+		{
+			input:    "[1,2] [3 4] [5,6]",
+			expected: ParseError{Interval: 2, Offset: 6, Line: 1, Column: 7, Text: "[3 4]", Snippet: "[1,2] [3 4] [5,6]"},
+		},
+		{
+			input:    "[1,2] [3,x] [5,6] [7,8] [9,10] [11,12]",
+			expected: ParseError{Interval: 2, Offset: 6, Line: 1, Column: 7, Text: "[3,x]", Snippet: "[1,2] [3,x] [5,6] [7,8] [9,10] "},
+		},
+		{
+			input:    "[1,2] [3,x",
+			expected: ParseError{Interval: 2, Offset: 6, Line: 1, Column: 7, Text: "[3,x", Snippet: "[1,2] [3,x"},
+		},
+		{
+			input:    "[1,2]\n[3,4]\r\n\t[5,1]",
+			expected: ParseError{Interval: 3, Offset: 14, Line: 3, Column: 2, Text: "[5,1]", Snippet: "[1,2]\n[3,4]\r\n\t[5,1]"},
+			reason:   errReversed,
+		},
+		{
+			input:    "[100000,200000] [300000,400000]\n[500000,y]",
+			expected: ParseError{Interval: 3, Offset: 32, Line: 2, Column: 1, Text: "[500000,y]", Snippet: "00] [300000,400000]\n[500000,y]"},
+		},
+	}
+
+	for _, test := range testcases {
+		_, err := Parse(strings.NewReader(test.input), Int)
+		assert.ErrorIs(t, err, errBadInput, fmt.Sprintf("testcase: %+v", test))
+		if test.reason != nil {
+			assert.ErrorIs(t, err, test.reason, fmt.Sprintf("testcase: %+v", test))
+		}
+
+		var parseErr *ParseError
+		if assert.ErrorAs(t, err, &parseErr, fmt.Sprintf("testcase: %+v", test)) {
+			parseErr.Err = nil
+			assert.Equal(t, test.expected, *parseErr, fmt.Sprintf("testcase: %+v", test))
+		}
+	}
+}
+
+func TestParseStrayBracket(t *testing.T) {
+	// more input than fits in the buffer of the scanner
+	input := "[1,2]]" + strings.Repeat(" [3,4]", 20000)
+
+	for _, strict := range []bool{false, true} {
+		_, err := ParseWith(strings.NewReader(input), Int, ParseOptions{Strict: strict})
+		assert.ErrorIs(t, err, errBadInput, fmt.Sprintf("strict: %t", strict))
+
+		var parseErr *ParseError
+		if assert.ErrorAs(t, err, &parseErr, fmt.Sprintf("strict: %t", strict)) {
+			assert.Equal(t, 2, parseErr.Interval, fmt.Sprintf("strict: %t", strict))
+			assert.Equal(t, int64(5), parseErr.Offset, fmt.Sprintf("strict: %t", strict))
+			assert.Equal(t, "]", parseErr.Text, fmt.Sprintf("strict: %t", strict))
+		}
+	}
+}
+
+func TestIsEmpty(t *testing.T) {
+	assert.False(t, Interval[float64]{X: 3, Y: 4, Bounds: Open}.isEmpty(Float64))
+	assert.True(t, Interval[float64]{X: 3, Y: 3, Bounds: LeftOpen}.isEmpty(Float64))
+	assert.True(t, Interval[int]{X: 3, Y: 4, Bounds: Open}.isEmpty(Int))
+	assert.False(t, Interval[int]{X: 3, Y: 4, Bounds: RightOpen}.isEmpty(Int))
+}
+
+func TestParseWithSkip(t *testing.T) {
+	var rejects []string
+	opts := ParseOptions{OnError: SkipOnError, Reject: func(err *ParseError) {
+		rejects = append(rejects, fmt.Sprintf("%d %d %s", err.Interval, err.Offset, err.Text))
+	}}
+
+	res, err := ParseWith(strings.NewReader("[1,2] [3 4] [5,x]\n[7,6] [8,9]"), Int, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "[1,2] [8,9]", Format(res, Int))
+	assert.Equal(t, []string{"2 6 [3 4]", "3 12 [5,x]", "4 18 [7,6]"}, rejects)
+
+	// without Reject, invalid intervals are skipped silently
+	res, err = ParseWith(strings.NewReader("[1,2] [3 4]"), Int, ParseOptions{OnError: SkipOnError})
+	assert.NoError(t, err)
+	assert.Equal(t, "[1,2]", Format(res, Int))
+
+	// a stray bracket in input larger than the buffer of
+	// the scanner is a single reject
+	rejects = nil
+	res, err = ParseWith(strings.NewReader("[1,2]]"+strings.Repeat(" [3,4]", 20000)), Int, opts)
+	assert.NoError(t, err)
+	assert.Len(t, res, 20001)
+	assert.Equal(t, []string{"2 5 ]"}, rejects)
+}
+
+func TestParseStrict(t *testing.T) {
+	testcases := []struct {
+		input    string
+		expected string
+		err      string
+	}{
+		{input: "", expected: ""},
+		{input: " [1,2]\n", expected: "[1,2]"},
+		{input: "[1, 2] ( -3 , +4 )", expected: "[1,2] (-3,4)"},
+		{input: "[1,2],[3,4] , (5,6]", expected: "[1,2] [3,4] (5,6]"},
+		{input: "[1,2][3,4]", expected: "[1,2] [3,4]"},
+		{input: "1,2]", err: "unexpected '1', expected opening bracket"},
+		{input: "[[1,2]]", err: "unexpected '[', expected endpoint"},
+		{input: "[1,2]]", err: "unexpected ']', expected opening bracket"},
+		{input: "[1,2] x [3,4]", err: "unexpected 'x', expected opening bracket"},
+		{input: "[1,2] ,, [3,4]", err: "unexpected ',', expected opening bracket"},
+		{input: ", [1,2]", err: "unexpected ',', expected opening bracket"},
+		{input: "[1,2],", err: "missing opening bracket at end of input"},
+		{input: "[1 2]", err: "unexpected '2', expected comma"},
+		{input: "[1,2,3]", err: "unexpected ',', expected closing bracket"},
+		{input: "[1,2", err: "missing closing bracket at end of input"},
+	}
+
+	for _, test := range testcases {
+		res, err := ParseWith(strings.NewReader(test.input), Int, ParseOptions{Strict: true})
+		if test.err != "" {
+			assert.ErrorIs(t, err, errBadInput, fmt.Sprintf("testcase: %+v", test))
+			assert.ErrorContains(t, err, test.err, fmt.Sprintf("testcase: %+v", test))
+			continue
+		}
+
+		assert.NoError(t, err, fmt.Sprintf("testcase: %+v", test))
+		assert.Equal(t, test.expected, Format(res, Int), fmt.Sprintf("testcase: %+v", test))
+	}
+}
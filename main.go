@@ -1,48 +1,549 @@
 package main
 
 import (
+	"bufio"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"math"
 	"os"
 	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"example.com/intervals"
 )
 
-type fileIndex struct {
-	key  interval
-	file *os.File
+// config holds the command line options
+// independent of the endpoint type.
+type config struct {
+	filePath  string
+	output    string
+	noClobber bool
+	tempDir   string
+	maxMemory int64
+	threshold int64
+	files     bool
+	fanIn     int
+	workers   int
+	parse     intervals.ParseOptions
+	merge     intervals.MergeOptions
+	format    intervals.FormatOptions
+	compress  intervals.Compression
+	spill     intervals.Compression
+}
+
+// fileOptions returns the options for file mode.
+func (cfg config) fileOptions() intervals.FileOptions {
+	output := cfg.output
+	if output == "-" {
+		output = ""
+	}
+
+	return intervals.FileOptions{
+		Output:            output,
+		NoClobber:         cfg.noClobber,
+		TempDir:           cfg.tempDir,
+		MaxMemory:         cfg.maxMemory,
+		InMemoryThreshold: cfg.threshold,
+		ChunkSize:         fileChunkSizeFromEnv(),
+		FanIn:             cfg.fanIn,
+		Workers:           cfg.workers,
+		Parse:             cfg.parse,
+		Merge:             cfg.merge,
+		Format:            cfg.format,
+		Compression:       cfg.compress,
+		SpillCompression:  cfg.spill,
+	}
 }
 
+var (
+	reversedPolicies = map[string]intervals.ReversedPolicy{
+		"reject": intervals.RejectReversed,
+		"swap":   intervals.SwapReversed,
+		"drop":   intervals.DropReversed,
+	}
+
+	emptyPolicies = map[string]intervals.EmptyPolicy{
+		"keep":   intervals.KeepEmpty,
+		"drop":   intervals.DropEmpty,
+		"reject": intervals.RejectEmpty,
+	}
+
+	errorPolicies = map[string]intervals.ErrorPolicy{
+		"fail": intervals.FailOnError,
+		"skip": intervals.SkipOnError,
+	}
+
+	inputEncodings = map[string]intervals.Encoding{
+		"text":   intervals.Text,
+		"json":   intervals.JSONArrays,
+		"csv":    intervals.CSV,
+		"tsv":    intervals.CSV,
+		"lines":  intervals.Lines,
+		"ndjson": intervals.NDJSON,
+		"binary": intervals.Binary,
+	}
+
+	outputEncodings = map[string]intervals.Encoding{
+		"text":         intervals.Text,
+		"json":         intervals.JSONArrays,
+		"json-objects": intervals.JSONObjects,
+		"lines":        intervals.Lines,
+		"ndjson":       intervals.NDJSON,
+		"binary":       intervals.Binary,
+	}
+
+	compressions = map[string]intervals.Compression{
+		"none": intervals.NoCompression,
+		"gzip": intervals.Gzip,
+		"zstd": intervals.Zstd,
+	}
+
+	csvBounds = map[string]intervals.Bounds{
+		"[]": intervals.Closed,
+		"(]": intervals.LeftOpen,
+		"[)": intervals.RightOpen,
+		"()": intervals.Open,
+	}
+)
+
 func main() {
-	var filePath string
-	flag.StringVar(&filePath, "f", "", "path to file containing list of intervals to merge.")
+	var cfg config
+	var endpointType string
+	var reversed, empty, onError, inputFormat, outputFormat, delimiter, bounds, compress, spill string
+	flag.StringVar(&cfg.filePath, "f", "", "path to file containing list of intervals to merge, - for standard input.")
+	flag.StringVar(&cfg.output, "o", "", "path to write the result of file mode to, - for standard output when merging (default result.txt, or standard output when reading standard input).")
+	flag.StringVar(&endpointType, "type", "int", "type of the interval endpoints: int, int64, uint64, float64, time (RFC 3339) or bigint (integers of any size).")
+	flag.BoolVar(&cfg.merge.Adjacent, "adjacent", false, "also merge intervals touching at consecutive integers, e.g. [1,2] and [3,4].")
+	flag.Int64Var(&cfg.merge.Gap, "gap", 0, "also merge intervals at most this many units apart (nanoseconds for time).")
+	flag.StringVar(&reversed, "reversed", "reject", "handling of reversed intervals such as [5,1]: reject, swap or drop.")
+	flag.StringVar(&empty, "empty", "keep", "handling of empty intervals such as (3,3): keep, drop or reject.")
+	flag.BoolVar(&cfg.parse.Strict, "strict", false, "reject input outside the interval syntax, e.g. [[1,2]] or text between intervals.")
+	flag.StringVar(&inputFormat, "input-format", "text", "format of the input: text, json for an array of [start,end] arrays or {\"start\":...,\"end\":...} objects, lines or ndjson for one text or json interval per line, csv or tsv - see -csv-start and -csv-end, or binary as written with -output-format binary. Columns of csv or tsv input other than the endpoints are ignored.")
+	flag.BoolVar(&cfg.parse.CSV.Header, "csv-header", false, "the first row of csv or tsv input is a header naming the columns.")
+	flag.StringVar(&cfg.parse.CSV.Start, "csv-start", "1", "column of the start of the intervals in csv or tsv input: a number starting at 1, or a name with -csv-header.")
+	flag.StringVar(&cfg.parse.CSV.End, "csv-end", "2", "column of the end of the intervals in csv or tsv input: a number starting at 1, or a name with -csv-header.")
+	flag.StringVar(&delimiter, "csv-delimiter", "", "field delimiter of csv or tsv input (default \",\" for csv, tab for tsv).")
+	flag.StringVar(&bounds, "csv-bounds", "[]", "bounds of the intervals in csv or tsv input: [], [), (] or ().")
+	flag.StringVar(&outputFormat, "output-format", "text", "format of the result: text, json, json-objects, lines or ndjson for one text or json object interval per line, or binary for a compact binary format.")
+	flag.StringVar(&compress, "compress", "", "compression of the result of file mode: none, gzip or zstd (default from the extension of -o, .gz or .zst). Compressed input is detected automatically.")
+	flag.StringVar(&spill, "compress-spill", "none", "compression of the temporary files of file mode: none, gzip or zstd.")
+	flag.StringVar(&onError, "on-error", "fail", "handling of invalid intervals: fail, or skip them and log them to the -rejects file.")
+	flag.StringVar(&rejects.path, "rejects", "rejects.txt", "path to log skipped invalid intervals to with -on-error skip, replacing an existing file unless -no-clobber is set.")
+	flag.BoolVar(&cfg.noClobber, "no-clobber", false, "fail instead of overwriting an existing result or rejects file.")
+	flag.Func("max-memory", "memory budget for file mode, e.g. 512MB; chunk size, workers and fan-in are reduced to fit it.", func(s string) error {
+		var err error
+		cfg.maxMemory, err = parseSize(s)
+		return err
+	})
+	flag.Func("in-memory-threshold", "inputs up to this size, e.g. 64MB, are merged in memory in file mode, larger ones are spilled to disk (default 1MB).", func(s string) error {
+		var err error
+		cfg.threshold, err = parseSize(s)
+		return err
+	})
+	flag.StringVar(&cfg.tempDir, "temp-dir", "", "directory for temporary files in file mode (default $TMPDIR or the system temp directory).")
+	flag.BoolVar(&cfg.files, "files", false, "treat the operands of set operations as paths to files.")
+	flag.IntVar(&cfg.workers, "workers", 0, "number of chunks processed in parallel in file mode (default GOMAXPROCS).")
+	flag.IntVar(&cfg.fanIn, "fan-in", intervals.DefaultFanIn, "maximum number of sorted runs merged at once in file mode.")
+	flag.Usage = usage
 	flag.Parse()
 
-	if filePath != "" {
-		res, err := processFile(filePath, fileChunkSizeFromEnv())
-		if err != nil {
-			log.Fatalf("failed to process file %q: %s\n", filePath, err.Error())
+	var ok bool
+	cfg.parse.Reversed, ok = reversedPolicies[reversed]
+	if !ok {
+		fatalf("unknown policy for reversed intervals %q\n", reversed)
+	}
+
+	cfg.parse.Empty, ok = emptyPolicies[empty]
+	if !ok {
+		fatalf("unknown policy for empty intervals %q\n", empty)
+	}
+
+	cfg.parse.OnError, ok = errorPolicies[onError]
+	if !ok {
+		fatalf("unknown policy for invalid intervals %q\n", onError)
+	}
+	cfg.parse.Encoding, ok = inputEncodings[inputFormat]
+	if !ok {
+		fatalf("unknown input format %q\n", inputFormat)
+	}
+
+	switch {
+	case delimiter != "":
+		r, size := utf8.DecodeRuneInString(delimiter)
+		if size != len(delimiter) {
+			fatalf("csv delimiter must be a single character, got %q\n", delimiter)
 		}
+		cfg.parse.CSV.Comma = r
+	case inputFormat == "tsv":
+		cfg.parse.CSV.Comma = '\t'
+	}
 
-		fmt.Printf("result written to file %q\n", res)
+	cfg.parse.CSV.Bounds, ok = csvBounds[bounds]
+	if !ok {
+		fatalf("unknown csv bounds %q\n", bounds)
+	}
+
+	cfg.format.Encoding, ok = outputEncodings[outputFormat]
+	if !ok {
+		fatalf("unknown output format %q\n", outputFormat)
+	}
+
+	cfg.compress = intervals.CompressionOf(cfg.output)
+	if compress != "" {
+		cfg.compress, ok = compressions[compress]
+		if !ok {
+			fatalf("unknown compression %q\n", compress)
+		}
+	}
+
+	cfg.spill, ok = compressions[spill]
+	if !ok {
+		fatalf("unknown compression %q\n", spill)
+	}
+
+	if cfg.parse.OnError == intervals.SkipOnError {
+		rejects.noClobber = cfg.noClobber
+		cfg.parse.Reject = rejects.add
+	}
+
+	switch endpointType {
+	case "int":
+		run(intervals.Int, cfg)
+	case "int64":
+		run(intervals.Int64, cfg)
+	case "uint64":
+		run(intervals.Uint64, cfg)
+	case "float64":
+		run(intervals.Float64, cfg)
+	case "time":
+		run(intervals.Time, cfg)
+	case "bigint":
+		run(intervals.BigInt, cfg)
+	default:
+		fatalf("unknown endpoint type %q\n", endpointType)
+	}
+
+	err := rejects.report()
+	if err != nil {
+		log.Fatalf("failed to write file %q: %s\n", rejects.path, err.Error())
+	}
+}
+
+// rejects logs the intervals skipped with -on-error skip.
+var rejects rejectLog
+
+// fatalf exits as log.Fatalf does, after writing out the
+// intervals skipped so far to the reject log.
+func fatalf(format string, v ...any) {
+	err := rejects.report()
+	if err != nil {
+		log.Printf("failed to write file %q: %s\n", rejects.path, err.Error())
+	}
+	log.Fatalf(format, v...)
+}
+
+// rejectLog writes the errors of skipped intervals to the
+// file at path, one per line. The file is only created
+// once there is an error to write, and must not exist
+// yet with noClobber.
+type rejectLog struct {
+	path      string
+	noClobber bool
+	f         *os.File
+	w         *bufio.Writer
+	err       error
+	// number of skipped intervals
+	n int
+}
+
+// add writes err to the log.
+func (l *rejectLog) add(err *intervals.ParseError) {
+	l.n++
+	if l.err != nil {
+		return
+	}
+
+	if l.f == nil {
+		flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if l.noClobber {
+			flags |= os.O_EXCL
+		}
+		l.f, l.err = os.OpenFile(l.path, flags, 0o666)
+		if l.err != nil {
+			return
+		}
+		l.w = bufio.NewWriter(l.f)
+	}
+
+	_, l.err = fmt.Fprintln(l.w, err)
+}
+
+// close flushes and closes the log, returning the
+// first error writing it.
+func (l *rejectLog) close() error {
+	if l.f == nil {
+		return l.err
+	}
+
+	if l.err == nil {
+		l.err = l.w.Flush()
+	}
+	if err := l.f.Close(); l.err == nil {
+		l.err = err
+	}
+	l.f = nil
+	return l.err
+}
+
+// report closes the log and prints the number of skipped
+// intervals to stderr, or returns the error writing it.
+func (l *rejectLog) report() error {
+	err := l.close()
+	if err != nil {
+		return err
+	}
+	if l.n > 0 {
+		fmt.Fprintf(os.Stderr, "skipped %d invalid intervals, see %q\n", l.n, l.path)
+	}
+	return nil
+}
+
+// usage prints how to invoke the program.
+func usage() {
+	fmt.Fprintln(flag.CommandLine.Output(), `usage:
+  go run . [FLAGS] "INTERVAL_LIST"
+  go run . [FLAGS] -f FILE|- [-o FILE|-]
+  ... | go run . [FLAGS] [-o FILE|-]
+  go run . [FLAGS] [-files] intersect|subtract|symdiff A B
+  go run . [FLAGS] [-files] gaps A ["UNIVERSE"]
+
+examples:
+  go run . "[1,2] [2,3]"
+  go run . intersect "[1,5] [8,10]" "[3,9]"
+  go run . gaps "[1,3] [5,7]" "[0,10]"
+
+flags:`)
+	flag.PrintDefaults()
+}
+
+// run merges the intervals given either in the file at
+// cfg.filePath or as the single command line argument,
+// with endpoints in domain d. If the first argument
+// names a set operation, it runs that instead.
+func run[T any](d intervals.Domain[T], cfg config) {
+	if flag.NArg() > 0 {
+		switch command := flag.Arg(0); command {
+		case "intersect", "subtract", "symdiff":
+			runSetOperation(d, cfg, command, flag.Args()[1:])
+			return
+		case "gaps", "complement":
+			runGaps(d, cfg, flag.Args()[1:])
+			return
+		}
+	}
+
+	if cfg.filePath == "" && flag.NArg() == 0 && stdinIsPiped() {
+		cfg.filePath = "-"
+	}
+
+	if cfg.filePath != "" {
+		runFile(d, cfg)
 	} else {
-		if len(os.Args) != 2 {
-			fmt.Println("usage: go run . \"INTERVAL_LIST\"")
-			fmt.Println("example: go run . \"[1,2] [2,3]\"")
+		if flag.NArg() != 1 {
+			flag.Usage()
 			os.Exit(1)
 		}
 
-		res, err := processString(os.Args[1])
+		res, err := parseAndMerge(d, cfg, flag.Arg(0))
+		if err != nil {
+			fatalf("failed to process input: %s\n", errorText(err))
+		}
+		printResult(res, d, cfg)
+	}
+}
+
+// runFile merges the intervals in the file at cfg.filePath,
+// or read from standard input if it is "-", and writes the
+// result to cfg.output.
+func runFile[T any](d intervals.Domain[T], cfg config) {
+	if cfg.output == "-" || cfg.output == "" && cfg.filePath == "-" {
+		in := os.Stdin
+		if cfg.filePath != "-" {
+			var err error
+			in, err = os.Open(cfg.filePath)
+			if err != nil {
+				fatalf("failed to open file %q: %s\n", cfg.filePath, errorText(err))
+			}
+			defer in.Close()
+		}
+
+		w := bufio.NewWriter(os.Stdout)
+		err := intervals.MergeStream(in, w, d, cfg.fileOptions())
+		if err == nil {
+			err = w.Flush()
+		}
+		if err != nil {
+			fatalf("failed to process input: %s\n", errorText(err))
+		}
+		return
+	}
+
+	var res string
+	var err error
+	if cfg.filePath == "-" {
+		res, err = intervals.MergeReader(os.Stdin, d, cfg.fileOptions())
+	} else {
+		res, err = intervals.MergeFile(cfg.filePath, d, cfg.fileOptions())
+	}
+	if err != nil {
+		fatalf("failed to process file %q: %s\n", cfg.filePath, errorText(err))
+	}
+
+	fmt.Printf("result written to file %q\n", res)
+}
+
+// stdinIsPiped reports whether standard input is
+// redirected from a file or pipe rather than a terminal.
+func stdinIsPiped() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice == 0
+}
+
+// runSetOperation applies the set operation named by command
+// to the operands in args, either interval lists or, with
+// cfg.files, paths to files containing them.
+func runSetOperation[T any](d intervals.Domain[T], cfg config, command string, args []string) {
+	if len(args) != 2 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if cfg.files {
+		if cfg.output == "-" {
+			fatalf("-o - is not supported by %s, the result of file mode is written to a file\n", command)
+		}
+
+		combine := intervals.IntersectFiles[T]
+		switch command {
+		case "subtract":
+			combine = intervals.SubtractFiles[T]
+		case "symdiff":
+			combine = intervals.SymmetricDifferenceFiles[T]
+		}
+
+		res, err := combine(args[0], args[1], d, cfg.fileOptions())
+		if err != nil {
+			fatalf("failed to process files %q and %q: %s\n", args[0], args[1], errorText(err))
+		}
+
+		fmt.Printf("result written to file %q\n", res)
+		return
+	}
+
+	a, err := parseAndMerge(d, cfg, args[0])
+	if err != nil {
+		fatalf("failed to process first operand: %s\n", errorText(err))
+	}
+
+	b, err := parseAndMerge(d, cfg, args[1])
+	if err != nil {
+		fatalf("failed to process second operand: %s\n", errorText(err))
+	}
+
+	combine := intervals.Intersect[T]
+	switch command {
+	case "subtract":
+		combine = intervals.Subtract[T]
+	case "symdiff":
+		combine = intervals.SymmetricDifference[T]
+	}
+
+	printResult(combine(a, b, d), d, cfg)
+}
+
+// runGaps prints the gaps between the intervals given in
+// args[0], either an interval list or, with cfg.files, the
+// path to a file containing it. If args[1] is given, it is
+// the single interval within which to look for gaps.
+func runGaps[T any](d intervals.Domain[T], cfg config, args []string) {
+	if len(args) != 1 && len(args) != 2 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var universe *intervals.Interval[T]
+	if len(args) == 2 {
+		list, err := intervals.Parse(strings.NewReader(args[1]), d)
+		if err != nil {
+			fatalf("failed to process universe: %s\n", errorText(err))
+		}
+		if len(list) != 1 {
+			fatalf("universe must be a single interval, got %d\n", len(list))
+		}
+		universe = &list[0]
+	}
+
+	if cfg.files {
+		if cfg.output == "-" {
+			fatalf("-o - is not supported by gaps, the result of file mode is written to a file\n")
+		}
+
+		var res string
+		var err error
+		if universe != nil {
+			res, err = intervals.ComplementFile(args[0], *universe, d, cfg.fileOptions())
+		} else {
+			res, err = intervals.GapsFile(args[0], d, cfg.fileOptions())
+		}
 		if err != nil {
-			log.Fatalf("failed to process input: %s\n", err.Error())
+			fatalf("failed to process file %q: %s\n", args[0], errorText(err))
 		}
-		fmt.Println(IntervalListToString(res))
+
+		fmt.Printf("result written to file %q\n", res)
+		return
+	}
+
+	list, err := parseAndMerge(d, cfg, args[0])
+	if err != nil {
+		fatalf("failed to process input: %s\n", errorText(err))
+	}
+
+	if universe != nil {
+		printResult(intervals.Complement(list, *universe, d), d, cfg)
+	} else {
+		printResult(intervals.Gaps(list, d), d, cfg)
 	}
 }
 
+// parseAndMerge parses the interval list s and merges it
+// according to cfg.
+func parseAndMerge[T any](d intervals.Domain[T], cfg config, s string) ([]intervals.Interval[T], error) {
+	list, err := intervals.ParseWith(strings.NewReader(s), d, cfg.parse)
+	if err != nil {
+		return nil, err
+	}
+
+	return intervals.MergeWith(list, d, cfg.merge)
+}
+
+// printResult writes list to standard output in the
+// format of cfg, followed by a newline unless it is binary.
+func printResult[T any](list []intervals.Interval[T], d intervals.Domain[T], cfg config) {
+	s := intervals.FormatWith(list, d, cfg.format)
+	if cfg.format.Encoding == intervals.Binary {
+		fmt.Print(s)
+		return
+	}
+	fmt.Println(s)
+}
+
 // fileChunkSizeFromEnv reads FILE_CHUNK_SIZE_MB from
-// the environment and returns it.
-// If the variable is not set, it returns a default.
+// the environment and returns it in bytes.
+// If the variable is not set, it returns 0, so that the
+// chunk size follows from the defaults or -max-memory.
 func fileChunkSizeFromEnv() int {
 	var fileChunkSize int
 	fileChunkSizeStr, ok := os.LookupEnv("FILE_CHUNK_SIZE_MB")
@@ -50,12 +551,59 @@ func fileChunkSizeFromEnv() int {
 		var err error
 		fileChunkSize, err = strconv.Atoi(fileChunkSizeStr)
 		if err != nil {
-			log.Fatalln("FILE_CHUNK_SIZE_MB must be a number greater than zero.")
+			fatalf("FILE_CHUNK_SIZE_MB must be a number greater than zero.\n")
 		}
 	} else {
-		// Default: 1MB
-		fileChunkSize = 1
+		return 0
 	}
 
 	return fileChunkSize * 1024 * 1024
 }
+
+// sizeUnits maps the suffixes accepted by parseSize
+// to their multiples.
+var sizeUnits = map[string]int64{
+	"":    1,
+	"B":   1,
+	"K":   1 << 10,
+	"KB":  1 << 10,
+	"KIB": 1 << 10,
+	"M":   1 << 20,
+	"MB":  1 << 20,
+	"MIB": 1 << 20,
+	"G":   1 << 30,
+	"GB":  1 << 30,
+	"GIB": 1 << 30,
+}
+
+// parseSize parses a size in bytes such as "4096",
+// "512MB" or "2G". Units are multiples of 1024.
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	digits := strings.TrimRight(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
+
+	unit, ok := sizeUnits[strings.ToUpper(strings.TrimSpace(s[len(digits):]))]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit in size %q", s)
+	}
+
+	n, err := strconv.ParseInt(strings.TrimSpace(digits), 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("size %q must be a number greater than zero", s)
+	}
+
+	if n > math.MaxInt64/unit {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	return n * unit, nil
+}
+
+// errorText returns the text of err, followed by the
+// input surrounding the interval for parse errors.
+func errorText(err error) string {
+	var parseErr *intervals.ParseError
+	if errors.As(err, &parseErr) {
+		return fmt.Sprintf("%s\nnear: %q", err, parseErr.Snippet)
+	}
+	return err.Error()
+}